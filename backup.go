@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const backupTimeFormat = "20060102-150405"
+
+// noBackup makes writeConfigContent skip the backup of the file it
+// replaces. It is set by --no-backup and by undo.
+var noBackup bool
+
+type Backup struct {
+	Path string
+	Time time.Time
+	Seq int
+}
+
+// backupDirectory returns where backups of the config file at path are kept:
+// --backup-dir, then $I3_BIND_BACKUP_DIR, then the config's own directory.
+func backupDirectory(path string) string {
+	if backupDir != "" {
+		return expandIncludePath(backupDir, ".")
+	}
+	if dir := os.Getenv("I3_BIND_BACKUP_DIR"); dir != "" {
+		return expandIncludePath(dir, ".")
+	}
+	return filepath.Dir(path)
+}
+
+// backupPrefix returns the file name prefix shared by all backups of the
+// config file at path. In a separate backup directory the prefix includes
+// the config's directory name, so ~/.config/i3/config and
+// ~/.config/sway/config don't share backups.
+func backupPrefix(path string) string {
+	if backupDirectory(path) != filepath.Dir(path) {
+		if absPath, err := filepath.Abs(path); err == nil {
+			return filepath.Base(filepath.Dir(absPath)) + "-" + filepath.Base(path) + ".backup."
+		}
+	}
+	return filepath.Base(path) + ".backup."
+}
+
+// createBackup writes content to a new timestamped backup next to the
+// config file at path and prunes old backups beyond --max-backups.
+func createBackup(path string, content []byte, mode os.FileMode) (string, error) {
+	dir := backupDirectory(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	stamp := time.Now().Format(backupTimeFormat)
+	base := filepath.Join(dir, backupPrefix(path)+stamp)
+
+	// Several writes within the same second get a numeric suffix instead of
+	// overwriting each other.
+	backupPath := base
+	for seq := 2; ; seq++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = fmt.Sprintf("%s-%d", base, seq)
+	}
+
+	if err := ioutil.WriteFile(backupPath, content, mode); err != nil {
+		return "", err
+	}
+
+	if err := pruneBackups(path, maxBackups); err != nil {
+		return backupPath, fmt.Errorf("failed to prune old backups: %v", err)
+	}
+	return backupPath, nil
+}
+
+// listBackups returns the timestamped backups of the config file at path,
+// newest first.
+func listBackups(path string) ([]Backup, error) {
+	dir := backupDirectory(path)
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %v", err)
+	}
+
+	prefix := backupPrefix(path)
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(name, prefix)
+		seq := 1
+		if len(suffix) > len(backupTimeFormat) {
+			n, err := strconv.Atoi(strings.TrimPrefix(suffix[len(backupTimeFormat):], "-"))
+			if err != nil {
+				continue
+			}
+			seq = n
+			suffix = suffix[:len(backupTimeFormat)]
+		}
+
+		stamp, err := time.ParseInLocation(backupTimeFormat, suffix, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), Time: stamp, Seq: seq})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].Seq > backups[j].Seq
+	})
+	return backups, nil
+}
+
+// pruneBackups deletes the oldest backups so that at most max remain. A max
+// of zero or less keeps every backup.
+func pruneBackups(path string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(max, len(backups)):] {
+		if err := os.Remove(backup.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreBackup(cmd *cobra.Command, args []string) {
+	backups, err := listBackups(configPath)
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	if len(backups) == 0 {
+		fatalf(exitNotFound, "No backups found for %s", configPath)
+	}
+
+	if restoreList {
+		if jsonOutput {
+			printResult("ok", "", backups)
+			return
+		}
+		fmt.Printf("Found %d backup(s) of %s:\n\n", len(backups), configPath)
+		for i, backup := range backups {
+			fmt.Printf("  %d. %s %s\n", i+1, keyColor.Sprint(backup.Time.Format("2006-01-02 15:04:05")), backup.Path)
+		}
+		return
+	}
+
+	selected := backups[0]
+	if len(args) == 1 {
+		found := false
+		for _, backup := range backups {
+			if backup.Path == args[0] || filepath.Base(backup.Path) == args[0] || strings.TrimPrefix(filepath.Base(backup.Path), backupPrefix(configPath)) == args[0] {
+				selected = backup
+				found = true
+				break
+			}
+		}
+		if !found {
+			fatalWithHints(exitNotFound, fmt.Sprintf("Backup %s not found", args[0]), "Use 'i3-bind restore --list' to see available backups")
+		}
+	}
+
+	content, err := ioutil.ReadFile(selected.Path)
+	if err != nil {
+		fatalf(exitError, "failed to read backup: %v", err)
+	}
+
+	if !assumeYes && !confirm(fmt.Sprintf("Restore %s over %s?", selected.Path, configPath)) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	if err := writeConfigContent(configPath, content); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	printSuccess("✓ Restored config from %s\n", selected.Path)
+	reloadIfRequested()
+}
+
+// undoChange restores the most recent backup and deletes it, so each undo
+// steps one change further back. The undone state is not backed up, as that
+// backup would be the next one undo restores.
+func undoChange(cmd *cobra.Command, args []string) {
+	if outputPath != "" {
+		fatalf(exitError, "undo does not support --output; use 'i3-bind restore' instead")
+	}
+	backups, err := listBackups(configPath)
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	if len(backups) == 0 {
+		fatalf(exitNotFound, "No backups found for %s, nothing to undo", configPath)
+	}
+	latest := backups[0]
+
+	content, err := ioutil.ReadFile(latest.Path)
+	if err != nil {
+		fatalf(exitError, "failed to read backup: %v", err)
+	}
+
+	if !assumeYes && !confirm(fmt.Sprintf("Undo the change made at %s, restoring %s?", latest.Time.Format("2006-01-02 15:04:05"), latest.Path)) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	noBackup = true
+	if err := writeConfigContent(configPath, content); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	if err := os.Remove(latest.Path); err != nil {
+		fatalf(exitError, "failed to remove backup: %v", err)
+	}
+	printSuccess("✓ Undid the change made at %s (%d backup(s) left)\n", latest.Time.Format("2006-01-02 15:04:05"), len(backups)-1)
+	reloadIfRequested()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// checkLineRegex matches the "Line N:" entries i3 and sway print for each
+// offending config line.
+var checkLineRegex = regexp.MustCompile(`Line\s+(\d+):`)
+
+// printCheckOutput prints the output of i3 -C, turning each error into a
+// "config:N: message" line, and returns the number of errors.
+func printCheckOutput(output string) int {
+	errorCount := 0
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "ERROR") {
+			fmt.Println(line)
+			continue
+		}
+		if matches := checkLineRegex.FindStringSubmatch(line); matches != nil {
+			errorColor.Printf("%s:%s: ", configPath, matches[1])
+			fmt.Println(strings.TrimSpace(line[strings.Index(line, matches[0])+len(matches[0]):]))
+			errorCount++
+			continue
+		}
+		errorColor.Println(line)
+	}
+	return errorCount
+}
+
+// checkConfig runs the window manager's own config parser over the config
+// and reports what it complains about.
+func checkConfig(cmd *cobra.Command, args []string) {
+	wm := "i3"
+	if isSwayConfig() {
+		wm = "sway"
+	}
+
+	if _, err := exec.LookPath(wm); err != nil {
+		fatalf(exitError, "%s not found in PATH, cannot check the config", wm)
+	}
+
+	output, runErr := exec.Command(wm, "-C", "-c", configPath).CombinedOutput()
+
+	if jsonOutput {
+		report := []string{}
+		if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+			report = strings.Split(trimmed, "\n")
+		}
+		if runErr != nil {
+			printResult("error", fmt.Sprintf("%s rejected %s", wm, configPath), report)
+			os.Exit(exitError)
+		}
+		printResult("ok", fmt.Sprintf("%s accepts %s", wm, configPath), report)
+		return
+	}
+
+	errorCount := printCheckOutput(string(output))
+	if errorCount > 0 || runErr != nil {
+		if errorCount > 0 {
+			errorColor.Printf("\n%s found %d error(s) in %s\n", wm, errorCount, configPath)
+		} else {
+			errorColor.Printf("\n%s -C failed for %s: %v\n", wm, configPath, runErr)
+		}
+		os.Exit(exitError)
+	}
+	printSuccess("✓ %s accepts %s\n", wm, configPath)
+}
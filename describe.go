@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"i3-bind/pkg/i3config"
+)
+
+// actionRule explains one i3 command. explain receives the submatches of
+// pattern and returns a sentence for new users.
+type actionRule struct {
+	pattern *regexp.Regexp
+	explain func(matches []string) string
+}
+
+var actionRules = []actionRule{
+	{regexp.MustCompile(`^exec(?:_always)?\s+.+$`), func(m []string) string {
+		exec, _ := parseExec(m[0])
+		for _, flag := range exec.Flags {
+			if flag == "--no-startup-id" {
+				return fmt.Sprintf("Run `%s` without startup notification, so the cursor doesn't show a busy indicator", exec.CommandLine)
+			}
+		}
+		return fmt.Sprintf("Run `%s`", exec.CommandLine)
+	}},
+	{regexp.MustCompile(`^kill$`), func(m []string) string {
+		return "Close the focused window"
+	}},
+	{regexp.MustCompile(`^focus\s+(left|right|up|down)$`), func(m []string) string {
+		return fmt.Sprintf("Move focus to the window %s", direction(m[1]))
+	}},
+	{regexp.MustCompile(`^focus\s+(parent|child)$`), func(m []string) string {
+		return fmt.Sprintf("Focus the %s container", m[1])
+	}},
+	{regexp.MustCompile(`^focus\s+mode_toggle$`), func(m []string) string {
+		return "Switch focus between tiling and floating windows"
+	}},
+	{regexp.MustCompile(`^move\s+(left|right|up|down)(?:\s+(\d+)\s*px)?$`), func(m []string) string {
+		if m[2] != "" {
+			return fmt.Sprintf("Move the focused window %s px %s", m[2], m[1])
+		}
+		return fmt.Sprintf("Move the focused window %s", direction(m[1]))
+	}},
+	{regexp.MustCompile(`^move\s+(?:container\s+|window\s+)?to\s+workspace\s+(?:number\s+)?(.+)$`), func(m []string) string {
+		return fmt.Sprintf("Move the focused window to workspace %s", strings.Trim(m[1], `"`))
+	}},
+	{regexp.MustCompile(`^move\s+scratchpad$`), func(m []string) string {
+		return "Hide the focused window in the scratchpad"
+	}},
+	{regexp.MustCompile(`^scratchpad\s+show$`), func(m []string) string {
+		return "Show or hide the next window from the scratchpad"
+	}},
+	{regexp.MustCompile(`^workspace\s+(next|prev|next_on_output|prev_on_output)$`), func(m []string) string {
+		if strings.HasPrefix(m[1], "next") {
+			return "Switch to the next workspace"
+		}
+		return "Switch to the previous workspace"
+	}},
+	{regexp.MustCompile(`^workspace\s+back_and_forth$`), func(m []string) string {
+		return "Switch back to the previously focused workspace"
+	}},
+	{regexp.MustCompile(`^workspace\s+(?:number\s+)?(.+)$`), func(m []string) string {
+		return fmt.Sprintf("Switch to workspace %s", strings.Trim(m[1], `"`))
+	}},
+	{regexp.MustCompile(`^reload$`), func(m []string) string {
+		return "Reload the i3 config file"
+	}},
+	{regexp.MustCompile(`^restart$`), func(m []string) string {
+		return "Restart i3 in place, keeping your windows and layout"
+	}},
+	{regexp.MustCompile(`^exit$`), func(m []string) string {
+		return "Exit i3 and end the session"
+	}},
+	{regexp.MustCompile(`^mode\s+"?default"?$`), func(m []string) string {
+		return "Leave the current mode and return to the normal keybindings"
+	}},
+	{regexp.MustCompile(`^mode\s+(?:--pango_markup\s+)?"?([^"]+)"?$`), func(m []string) string {
+		return fmt.Sprintf("Enter the %q mode; its own keybindings apply until you leave it", m[1])
+	}},
+	{regexp.MustCompile(`^split\s+(h|horizontal)$`), func(m []string) string {
+		return "Open the next window to the right of the focused one"
+	}},
+	{regexp.MustCompile(`^split\s+(v|vertical)$`), func(m []string) string {
+		return "Open the next window below the focused one"
+	}},
+	{regexp.MustCompile(`^split\s+toggle$`), func(m []string) string {
+		return "Toggle the split direction for the next window"
+	}},
+	{regexp.MustCompile(`^layout\s+toggle\s+split$`), func(m []string) string {
+		return "Toggle the container between horizontal and vertical splits"
+	}},
+	{regexp.MustCompile(`^layout\s+(stacking|tabbed|splith|splitv|default)$`), func(m []string) string {
+		return fmt.Sprintf("Switch the container to the %s layout", m[1])
+	}},
+	{regexp.MustCompile(`^fullscreen(?:\s+toggle)?$`), func(m []string) string {
+		return "Toggle fullscreen for the focused window"
+	}},
+	{regexp.MustCompile(`^floating\s+toggle$`), func(m []string) string {
+		return "Toggle the focused window between tiling and floating"
+	}},
+	{regexp.MustCompile(`^sticky\s+toggle$`), func(m []string) string {
+		return "Keep the floating window visible on every workspace, or stop doing so"
+	}},
+	{regexp.MustCompile(`^resize\s+(grow|shrink)\s+(width|height)\s+(\d+)\s*px(?:\s+or\s+(\d+)\s*ppt)?$`), func(m []string) string {
+		verb := "Grow"
+		if m[1] == "shrink" {
+			verb = "Shrink"
+		}
+		text := fmt.Sprintf("%s the window %s by %s px", verb, m[2], m[3])
+		if m[4] != "" {
+			text += fmt.Sprintf(" (%s%% of the screen for tiled windows)", m[4])
+		}
+		return text
+	}},
+	{regexp.MustCompile(`^nop(?:\s.*)?$`), func(m []string) string {
+		return "Do nothing; the key is bound only so it isn't passed to applications"
+	}},
+}
+
+// execAction is an exec or exec_always command taken apart.
+type execAction struct {
+	Command string `json:"command"`
+	Flags []string `json:"exec_flags,omitempty"`
+	CommandLine string `json:"command_line"`
+	Program string `json:"program"`
+}
+
+var execRegex = regexp.MustCompile(`^(exec(?:_always)?)((?:\s+--[^\s]+)*)\s+(.+)$`)
+
+// parseExec takes apart a single exec command such as
+// "exec --no-startup-id pactl set-sink-mute 0 toggle" into the command, its
+// flags, the command line and the program it runs.
+func parseExec(command string) (execAction, bool) {
+	matches := execRegex.FindStringSubmatch(strings.TrimSpace(command))
+	if matches == nil {
+		return execAction{}, false
+	}
+	exec := execAction{
+		Command: matches[1],
+		Flags: strings.Fields(matches[2]),
+		CommandLine: strings.TrimSpace(matches[3]),
+	}
+
+	// A quoted command line is passed to the shell as a whole, so the
+	// program is the first word inside the quotes.
+	line := strings.Trim(exec.CommandLine, `"'`)
+	if fields := strings.Fields(line); len(fields) > 0 {
+		exec.Program = fields[0]
+		// Skip environment assignments like FOO=1 before the program.
+		for _, field := range fields {
+			if !strings.Contains(field, "=") || strings.HasPrefix(field, "=") {
+				exec.Program = field
+				break
+			}
+		}
+	}
+	return exec, true
+}
+
+// actionExecs returns the exec commands chained in an action.
+func actionExecs(action string) []execAction {
+	var execs []execAction
+	for _, command := range i3config.SplitCommands(action) {
+		if exec, ok := parseExec(command); ok {
+			execs = append(execs, exec)
+		}
+	}
+	return execs
+}
+
+// missingPrograms returns the programs run by exec commands in action that
+// aren't found in $PATH. Config variables are expanded first; a program that
+// still depends on the shell, such as $HOME/bin/lock, isn't checked.
+func missingPrograms(action string, vars map[string]string) []string {
+	var missing []string
+	for _, command := range actionExecs(expandVariables(action, vars)) {
+		program := command.Program
+		if rest, ok := strings.CutPrefix(program, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				program = filepath.Join(home, rest)
+			}
+		}
+		if program == "" || strings.ContainsAny(program, "$`~") {
+			continue
+		}
+		if _, err := exec.LookPath(program); err != nil {
+			missing = append(missing, command.Program)
+		}
+	}
+	return missing
+}
+
+func direction(dir string) string {
+	if dir == "up" || dir == "down" {
+		return dir
+	}
+	return "to the " + dir
+}
+
+// describeAction explains each command of an action, one per line.
+func describeAction(action string) []string {
+	var lines []string
+	for _, command := range i3config.SplitCommands(action) {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+		explained := false
+		for _, rule := range actionRules {
+			if matches := rule.pattern.FindStringSubmatch(command); matches != nil {
+				lines = append(lines, rule.explain(matches))
+				explained = true
+				break
+			}
+		}
+		if !explained {
+			lines = append(lines, fmt.Sprintf("Run the i3 command: %s", command))
+		}
+	}
+	return lines
+}
+
+func describeBinding(cmd *cobra.Command, args []string) {
+	_, bindings, err := loadBindings()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	binding, ok := i3config.FindBinding(bindings, args[0])
+	if !ok {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, args[0]))
+	}
+
+	if jsonOutput {
+		printResult("ok", "", map[string]interface{}{
+			"binding": binding,
+			"explanation": describeAction(binding.Action),
+			"execs": append([]execAction{}, actionExecs(binding.Action)...),
+		})
+		return
+	}
+
+	fmt.Printf("%s %s (%s)\n", formatKey(binding), modeColor.Sprintf("[mode: %s]", modeLabel(binding.Mode)), formatLocation(binding))
+	fmt.Printf("  Action: %s\n", actionColor.Sprint(binding.Action))
+	for _, exec := range actionExecs(binding.Action) {
+		fmt.Printf("  Program: %s\n", actionColor.Sprint(exec.Program))
+		if len(exec.Flags) > 0 {
+			fmt.Printf("  Exec flags: %s\n", codeColor.Sprint(strings.Join(exec.Flags, " ")))
+		}
+	}
+	if binding.Comment != "" {
+		fmt.Printf("  Comment: %s\n", commentColor.Sprint(binding.Comment))
+	}
+	fmt.Println()
+	for _, line := range describeAction(binding.Action) {
+		fmt.Printf("  %s\n", line)
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"i3-bind/pkg/i3config"
+)
+
+// changedBinding is a key bound in both configs with a different action or
+// comment.
+type changedBinding struct {
+	A Binding `json:"a"`
+	B Binding `json:"b"`
+}
+
+type bindingDiff struct {
+	OnlyInA []Binding `json:"only_in_a"`
+	OnlyInB []Binding `json:"only_in_b"`
+	Changed []changedBinding `json:"changed"`
+}
+
+// diffBindings compares two sets of bindings key by key. For keys bound more
+// than once, the last definition is compared, since that is the one i3 uses.
+func diffBindings(a, b []Binding) bindingDiff {
+	diff := bindingDiff{OnlyInA: []Binding{}, OnlyInB: []Binding{}, Changed: []changedBinding{}}
+
+	index := func(bindings []Binding) ([]string, map[string]Binding) {
+		var order []string
+		byID := make(map[string]Binding)
+		for _, binding := range bindings {
+			id := bindingID(binding)
+			if _, ok := byID[id]; !ok {
+				order = append(order, id)
+			}
+			byID[id] = binding
+		}
+		return order, byID
+	}
+	orderA, byA := index(a)
+	orderB, byB := index(b)
+
+	for _, id := range orderA {
+		other, ok := byB[id]
+		if !ok {
+			diff.OnlyInA = append(diff.OnlyInA, byA[id])
+			continue
+		}
+		if !sameAction(byA[id].Action, other.Action) || byA[id].Comment != other.Comment {
+			diff.Changed = append(diff.Changed, changedBinding{A: byA[id], B: other})
+		}
+	}
+	for _, id := range orderB {
+		if _, ok := byA[id]; !ok {
+			diff.OnlyInB = append(diff.OnlyInB, byB[id])
+		}
+	}
+	return diff
+}
+
+// sameAction compares two actions, leaving out exec flags such as
+// --no-startup-id with --ignore-exec-flags.
+func sameAction(a, b string) bool {
+	if ignoreExecFlags {
+		return stripExecFlags(a) == stripExecFlags(b)
+	}
+	return a == b
+}
+
+// stripExecFlags drops --no-startup-id from an exec action, the one flag i3
+// knows, so "exec --no-startup-id foo" reads as "exec foo". Other actions
+// are returned unchanged.
+func stripExecFlags(action string) string {
+	exec, ok := parseExec(action)
+	if !ok {
+		return action
+	}
+	for _, flag := range exec.Flags {
+		if flag != "--no-startup-id" {
+			return action
+		}
+	}
+	return exec.Command + " " + exec.CommandLine
+}
+
+// diffLabel formats a binding's key with its mode for diff output.
+func diffLabel(binding Binding) string {
+	if binding.Mode != "" {
+		return fmt.Sprintf("%s %s", formatKey(binding), modeColor.Sprintf("[mode: %s]", binding.Mode))
+	}
+	return formatKey(binding)
+}
+
+func diffComment(comment string) string {
+	if comment == "" {
+		return "(no comment)"
+	}
+	return commentColor.Sprintf("# %s", comment)
+}
+
+func diffConfigs(cmd *cobra.Command, args []string) {
+	var sides [2][]Binding
+	for i, path := range args {
+		files, err := loadConfigFilesFrom(path)
+		if err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		sides[i] = allBindings(files)
+	}
+
+	diff := diffBindings(sides[0], sides[1])
+	total := len(diff.OnlyInA) + len(diff.OnlyInB) + len(diff.Changed)
+
+	if jsonOutput {
+		if total > 0 {
+			printResult("error", fmt.Sprintf("Found %d difference(s) between %s and %s", total, args[0], args[1]), diff)
+			os.Exit(exitError)
+		}
+		printResult("ok", fmt.Sprintf("%s and %s have the same keybindings", args[0], args[1]), diff)
+		return
+	}
+
+	if total == 0 {
+		printSuccess("✓ %s and %s have the same keybindings\n", args[0], args[1])
+		return
+	}
+
+	printBindingDiff(diff, args[0], args[1])
+	os.Exit(exitError)
+}
+
+// printBindingDiff prints a diff of two sets of bindings labeled nameA and
+// nameB, ending with a summary line.
+func printBindingDiff(diff bindingDiff, nameA, nameB string) {
+	if sideBySide {
+		printSideBySide(diff, nameA, nameB)
+		return
+	}
+	fmt.Printf("%s %s\n%s %s\n", errorColor.Sprint("---"), nameA, successColor.Sprint("+++"), nameB)
+	if len(diff.OnlyInA) > 0 {
+		fmt.Printf("\nOnly in %s:\n", nameA)
+		for _, binding := range diff.OnlyInA {
+			fmt.Printf("  %s %s -> %s\n", errorColor.Sprint("-"), diffLabel(binding), actionColor.Sprint(binding.Action))
+		}
+	}
+	if len(diff.OnlyInB) > 0 {
+		fmt.Printf("\nOnly in %s:\n", nameB)
+		for _, binding := range diff.OnlyInB {
+			fmt.Printf("  %s %s -> %s\n", successColor.Sprint("+"), diffLabel(binding), actionColor.Sprint(binding.Action))
+		}
+	}
+	if len(diff.Changed) > 0 {
+		fmt.Println("\nChanged:")
+		for _, change := range diff.Changed {
+			fmt.Printf("  %s %s\n", warningColor.Sprint("~"), diffLabel(change.A))
+			if !sameAction(change.A.Action, change.B.Action) {
+				fmt.Printf("      %s %s\n", errorColor.Sprint("-"), actionColor.Sprint(change.A.Action))
+				fmt.Printf("      %s %s\n", successColor.Sprint("+"), actionColor.Sprint(change.B.Action))
+			}
+			if change.A.Comment != change.B.Comment {
+				fmt.Printf("      %s %s\n", errorColor.Sprint("-"), diffComment(change.A.Comment))
+				fmt.Printf("      %s %s\n", successColor.Sprint("+"), diffComment(change.B.Comment))
+			}
+		}
+	}
+	fmt.Printf("\n%d only in %s, %d only in %s, %d changed\n", len(diff.OnlyInA), nameA, len(diff.OnlyInB), nameB, len(diff.Changed))
+}
+
+// diffColumns returns the width of the terminal from $COLUMNS, or 80.
+func diffColumns() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 80
+}
+
+// sideText describes a binding in one column of the side-by-side view.
+func sideText(binding Binding) string {
+	text := binding.Key
+	if binding.Mode != "" {
+		text += fmt.Sprintf(" [mode: %s]", binding.Mode)
+	}
+	text += " -> " + binding.Action
+	if binding.Comment != "" {
+		text += " # " + binding.Comment
+	}
+	return text
+}
+
+// fitColumn pads or truncates text to exactly width characters.
+func fitColumn(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		if width < 1 {
+			return ""
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return text + strings.Repeat(" ", width-len(runes))
+}
+
+// printSideBySide prints a diff like printBindingDiff, with the bindings of
+// nameA on the left and those of nameB on the right, marked like diff -y:
+// < only on the left, > only on the right and | changed.
+func printSideBySide(diff bindingDiff, nameA, nameB string) {
+	width := (diffColumns() - 5) / 2
+	if width < 20 {
+		width = 20
+	}
+	row := func(left, marker, right string, leftColor, markerColor, rightColor *color.Color) {
+		line := "  " + leftColor.Sprint(fitColumn(left, width)) + " " + markerColor.Sprint(marker) + " " + rightColor.Sprint(fitColumn(right, width))
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+
+	plain := color.New()
+	row(nameA, " ", nameB, errorColor, plain, successColor)
+	row(strings.Repeat("-", width), " ", strings.Repeat("-", width), plain, plain, plain)
+	for _, binding := range diff.OnlyInA {
+		row(sideText(binding), "<", "", errorColor, errorColor, plain)
+	}
+	for _, binding := range diff.OnlyInB {
+		row("", ">", sideText(binding), plain, successColor, successColor)
+	}
+	for _, change := range diff.Changed {
+		row(sideText(change.A), "|", sideText(change.B), errorColor, warningColor, successColor)
+	}
+	fmt.Printf("\n%d only in %s, %d only in %s, %d changed\n", len(diff.OnlyInA), nameA, len(diff.OnlyInB), nameB, len(diff.Changed))
+}
+
+// parseSince parses a --since duration, which besides time.ParseDuration
+// units accepts whole days such as 2d.
+func parseSince(text string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %s", text)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	since, err := time.ParseDuration(text)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("invalid duration %s (expected e.g. 30m, 1h or 2d)", text)
+	}
+	return since, nil
+}
+
+// showChanges diffs the config as it was --since ago against the current
+// one. Each backup holds the config as it was right before a change, so the
+// state at the cutoff is the oldest backup taken after it.
+func showChanges(cmd *cobra.Command, args []string) {
+	since, err := parseSince(changesSince)
+	if err != nil {
+		fatalf(exitError, "%v", err)
+	}
+	cutoff := time.Now().Add(-since)
+
+	current, err := readConfigFile(configPath)
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	backups, err := listBackups(configPath)
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	var base *Backup
+	for i := range backups {
+		if !backups[i].Time.Before(cutoff) {
+			base = &backups[i]
+		}
+	}
+	if base == nil {
+		diff := bindingDiff{OnlyInA: []Binding{}, OnlyInB: []Binding{}, Changed: []changedBinding{}}
+		if jsonOutput {
+			printResult("ok", fmt.Sprintf("No changes to %s in the last %s", configPath, changesSince), diff)
+			return
+		}
+		printSuccess("✓ No changes to %s in the last %s\n", configPath, changesSince)
+		return
+	}
+
+	before, err := readConfigFile(base.Path)
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	diff := diffBindings(i3config.ParseLines(before), i3config.ParseLines(current))
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Changes to %s since %s", configPath, base.Time.Format("2006-01-02 15:04:05")), diff)
+		return
+	}
+	if len(diff.OnlyInA)+len(diff.OnlyInB)+len(diff.Changed) == 0 {
+		printSuccess("✓ No keybinding changes to %s in the last %s\n", configPath, changesSince)
+		return
+	}
+	printBindingDiff(diff, base.Time.Format("2006-01-02 15:04:05"), "now")
+}
+
+// lineChange is one line removed from or added to a file, with its 1-based
+// line number in the old or new version respectively.
+type lineChange struct {
+	Removed bool
+	Line int
+	Text string
+}
+
+// lineChanges returns the lines removed from before and added in after,
+// using a longest common subsequence so unrelated lines are left out.
+func lineChanges(before, after []string) []lineChange {
+	// common[i][j] is the LCS length of before[i:] and after[j:].
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var changes []lineChange
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			changes = append(changes, lineChange{Removed: true, Line: i + 1, Text: before[i]})
+			i++
+		default:
+			changes = append(changes, lineChange{Line: j + 1, Text: after[j]})
+			j++
+		}
+	}
+	return changes
+}
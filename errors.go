@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"i3-bind/pkg/i3config"
+)
+
+// Exit codes returned by every command, documented in the README.
+const (
+	exitError = 1
+	exitNotFound = 2
+	exitExists = 3
+	exitConfigMissing = 4
+)
+
+var (
+	// errNotFound is shared with i3config, so its errors map to the same
+	// exit code.
+	errNotFound = i3config.ErrNotFound
+	errConfigMissing = errors.New("i3 config file not found")
+)
+
+// notFoundError reports a missing keybinding, mode or backup in the usual
+// "Keybinding x not found" wording.
+func notFoundError(what string, name string) error {
+	return fmt.Errorf("%s %s %w", what, name, errNotFound)
+}
+
+// keyNotFoundError is notFoundError for a key, suggesting the bound keys
+// closest to it in case of a typo.
+func keyNotFoundError(bindings []Binding, key string) error {
+	err := notFoundError("Keybinding", key)
+	keys := closestKeys(bindings, key)
+	switch len(keys) {
+	case 0:
+		return err
+	case 1:
+		return fmt.Errorf("%w; did you mean %s?", err, keys[0])
+	}
+	return fmt.Errorf("%w; did you mean %s or %s?", err, strings.Join(keys[:len(keys)-1], ", "), keys[len(keys)-1])
+}
+
+// exitCode maps an error to the exit code documented for its kind.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errConfigMissing):
+		return exitConfigMissing
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, i3config.ErrExists):
+		return exitExists
+	}
+	return exitError
+}
@@ -3,11 +3,17 @@ module i3-bind
 go 1.24.3
 
 require (
-	github.com/fatih/color v1.18.0 // indirect
+	github.com/BurntSushi/toml v1.6.0
+	github.com/fatih/color v1.18.0
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 )
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// dotQuote quotes s as a Graphviz ID.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// modeEdge is a transition between two modes and the keys that make it.
+type modeEdge struct {
+	From string `json:"from"`
+	To string `json:"to"`
+	Keys []string `json:"keys"`
+}
+
+// modeTransitions returns the modes in the order they are first seen,
+// default first, and the transitions between them. Both ends of an edge are
+// resolved through vars, so a mode named with a $variable is one node.
+func modeTransitions(bindings []Binding, vars map[string]string) ([]string, []modeEdge) {
+	modes := []string{"default"}
+	seenModes := map[string]bool{"default": true}
+	addMode := func(mode string) {
+		if !seenModes[mode] {
+			seenModes[mode] = true
+			modes = append(modes, mode)
+		}
+	}
+
+	var edges []modeEdge
+	edgeIndex := make(map[[2]string]int)
+
+	for _, binding := range bindings {
+		from := modeLabel(strings.Trim(expandVariables(binding.Mode, vars), `"`))
+		addMode(from)
+		if binding.ModeSwitch == "" {
+			continue
+		}
+		to := strings.Trim(expandVariables(binding.ModeSwitch, vars), `"`)
+		addMode(to)
+
+		id := [2]string{from, to}
+		i, ok := edgeIndex[id]
+		if !ok {
+			i = len(edges)
+			edgeIndex[id] = i
+			edges = append(edges, modeEdge{From: from, To: to})
+		}
+		edges[i].Keys = append(edges[i].Keys, binding.Key)
+	}
+	return modes, edges
+}
+
+// printModeGraph prints the mode transitions as a Graphviz digraph: one node
+// per mode and one edge per pair of modes, labeled with the keys that switch
+// between them.
+func printModeGraph(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	bindings := allBindings(files)
+	vars := parseVariables(allLines(files))
+	if expandVars {
+		bindings = expandBindings(bindings, vars)
+	}
+	modes, edges := modeTransitions(bindings, vars)
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Found %d mode(s) and %d transition(s)", len(modes), len(edges)), map[string]interface{}{
+			"modes": modes,
+			"edges": append([]modeEdge{}, edges...),
+		})
+		return
+	}
+
+	fmt.Println("digraph i3_modes {")
+	fmt.Println("  rankdir=LR;")
+	for _, mode := range modes {
+		shape := "ellipse"
+		if mode == "default" {
+			shape = "doublecircle"
+		}
+		fmt.Printf("  %s [shape=%s];\n", dotQuote(mode), shape)
+	}
+	for _, e := range edges {
+		fmt.Printf("  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(strings.Join(e.Keys, ", ")))
+	}
+	fmt.Println("}")
+}
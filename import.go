@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"i3-bind/pkg/i3config"
+)
+
+var (
+	importDryRun bool
+	importPrune bool
+)
+
+// importEntry is one binding in an import file. YAML is a superset of JSON,
+// so the same struct decodes both formats.
+type importEntry struct {
+	Key string `yaml:"key" json:"key"`
+	Action string `yaml:"action" json:"action"`
+	Comment string `yaml:"comment" json:"comment"`
+	Mode string `yaml:"mode" json:"mode"`
+}
+
+func readImportFile(path string) ([]importEntry, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %v", err)
+	}
+
+	var entries []importEntry
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %v", err)
+	}
+
+	for i, entry := range entries {
+		if entry.Key == "" || entry.Action == "" {
+			return nil, fmt.Errorf("entry %d in %s needs both a key and an action", i+1, path)
+		}
+		if entry.Mode == "default" {
+			entries[i].Mode = ""
+		}
+	}
+	return entries, nil
+}
+
+// importSummary tallies the outcome of every entry of an import, and the
+// bindings removed by --prune.
+type importSummary struct {
+	Added int `json:"added"`
+	Updated int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Pruned int `json:"pruned"`
+}
+
+func (s importSummary) String() string {
+	text := fmt.Sprintf("%d added, %d updated, %d unchanged", s.Added, s.Updated, s.Unchanged)
+	if importPrune {
+		text += fmt.Sprintf(", %d pruned", s.Pruned)
+	}
+	return text
+}
+
+// findInMode returns the first binding for key within mode.
+func findInMode(bindings []Binding, key, mode string) (Binding, bool) {
+	for _, binding := range bindings {
+		if binding.Mode == mode && i3config.KeysMatch(binding.Key, key) {
+			return binding, true
+		}
+	}
+	return Binding{}, false
+}
+
+func importBindings(cmd *cobra.Command, args []string) {
+	entries, err := readImportFile(args[0])
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	// Work on copies of every file's lines and re-parse after each change,
+	// so line numbers stay correct as bindings are added and removed.
+	contents := make(map[string][]string)
+	for _, file := range files {
+		contents[file.Path] = append([]string(nil), file.Lines...)
+	}
+	changed := make(map[string]bool)
+	current := func() []Binding {
+		var bindings []Binding
+		for _, file := range files {
+			for _, binding := range i3config.ParseLines(contents[file.Path]) {
+				binding.SourceFile = file.Path
+				bindings = append(bindings, binding)
+			}
+		}
+		return bindings
+	}
+
+	// Each entry's outcome is logged with --verbose, or on stdout as the
+	// preview of --dry-run. With --side-by-side the changes are shown as one
+	// diff at the end instead.
+	var summary importSummary
+	printStep := func(format string, a ...interface{}) {
+		switch {
+		case sideBySide:
+		case importDryRun:
+			printInfo("  "+format+"\n", a...)
+		default:
+			printVerbose(format, a...)
+		}
+	}
+
+	for _, entry := range entries {
+		existing, found := findInMode(current(), entry.Key, entry.Mode)
+		if !found {
+			lines := contents[configPath]
+			if entry.Comment != "" {
+				lines, err = i3config.InsertBinding(lines, fmt.Sprintf("bindsym %s %s # %s", entry.Key, entry.Action, entry.Comment), entry.Mode)
+			} else {
+				lines, err = i3config.InsertBinding(lines, fmt.Sprintf("bindsym %s %s", entry.Key, entry.Action), entry.Mode)
+			}
+			if err != nil {
+				fatalf(exitCode(err), "%s: %v", entry.Key, err)
+			}
+			contents[configPath] = lines
+			changed[configPath] = true
+			printStep("%s %s -> %s", successColor.Sprint("+"), keyColor.Sprint(entry.Key), actionColor.Sprint(entry.Action))
+			summary.Added++
+			continue
+		}
+
+		path := existing.SourceFile
+		updated := false
+		if !sameAction(existing.Action, entry.Action) {
+			contents[path] = i3config.SetBindingAction(contents[path], existing, entry.Action)
+			changed[path] = true
+			printStep("%s %s -> %s (was %s)", warningColor.Sprint("~"), keyColor.Sprint(entry.Key), actionColor.Sprint(entry.Action), existing.Action)
+			existing, _ = findInMode(current(), entry.Key, entry.Mode)
+			updated = true
+		}
+		if entry.Comment != "" && existing.Comment != entry.Comment {
+			contents[path] = i3config.SetBindingComment(contents[path], existing, entry.Comment)
+			changed[path] = true
+			printStep("%s %s %s", warningColor.Sprint("~"), keyColor.Sprint(entry.Key), commentColor.Sprintf("# %s", entry.Comment))
+			updated = true
+		}
+		if updated {
+			summary.Updated++
+		} else {
+			printStep("%s %s -> %s (unchanged)", "=", keyColor.Sprint(entry.Key), actionColor.Sprint(entry.Action))
+			summary.Unchanged++
+		}
+	}
+
+	if importPrune {
+		for {
+			pruned := false
+			for _, binding := range current() {
+				keep := false
+				for _, entry := range entries {
+					if binding.Mode == entry.Mode && i3config.KeysMatch(binding.Key, entry.Key) {
+						keep = true
+						break
+					}
+				}
+				if keep {
+					continue
+				}
+
+				contents[binding.SourceFile] = i3config.RemoveBinding(contents[binding.SourceFile], binding, false)
+				changed[binding.SourceFile] = true
+				printStep("%s %s -> %s", errorColor.Sprint("-"), keyColor.Sprint(binding.Key), actionColor.Sprint(binding.Action))
+				summary.Pruned++
+				pruned = true
+				break
+			}
+			if !pruned {
+				break
+			}
+		}
+	}
+
+	if len(changed) == 0 {
+		printSuccess("✓ Config already matches the import file (%s)\n", summary)
+		if jsonOutput {
+			printResult("ok", "", summary)
+		}
+		return
+	}
+
+	if sideBySide && !quiet && !jsonOutput {
+		printBindingDiff(diffBindings(allBindings(files), current()), configPath, args[0])
+	}
+
+	if importDryRun {
+		if jsonOutput {
+			printResult("ok", fmt.Sprintf("Dry run: %s; no changes written", summary), summary)
+			return
+		}
+		printInfo("\nDry run: %s; no changes written\n", summary)
+		return
+	}
+
+	for _, file := range files {
+		if !changed[file.Path] {
+			continue
+		}
+		if err := writeConfigFile(file.Path, contents[file.Path]); err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+	}
+	printSuccess("✓ Imported keybindings from %s: %s\n", args[0], summary)
+	reloadIfRequested()
+	if jsonOutput {
+		printResult("ok", "", summary)
+	}
+}
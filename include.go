@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"i3-bind/pkg/i3config"
+)
+
+var includeRegex = regexp.MustCompile(`^\s*include\s+(.+?)\s*$`)
+
+type configFile struct {
+	Path string
+	Lines []string
+}
+
+// expandIncludePath resolves an include pattern the way i3 does: ~ and
+// environment variables are expanded and relative paths are taken from the
+// directory of the including file.
+func expandIncludePath(pattern, baseDir string) string {
+	pattern = strings.Trim(pattern, `"'`)
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = filepath.Join(home, strings.TrimPrefix(pattern, "~"))
+		}
+	}
+	pattern = os.ExpandEnv(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	return pattern
+}
+
+// loadConfigFiles reads the main config and every file it pulls in through
+// include directives, depth first in the order i3 processes them. Each file
+// is read at most once, which also guards against include cycles.
+func loadConfigFiles() ([]configFile, error) {
+	return loadConfigFilesFrom(configPath)
+}
+
+// loadConfigFilesFrom is loadConfigFiles for a config other than --config.
+func loadConfigFilesFrom(mainPath string) ([]configFile, error) {
+	var files []configFile
+	seen := make(map[string]bool)
+
+	var load func(path string, required bool) error
+	load = func(path string, required bool) error {
+		absPath, err := filepath.Abs(path)
+		if err == nil && seen[absPath] {
+			return nil
+		}
+		seen[absPath] = true
+
+		lines, err := readConfigFile(path)
+		if err != nil {
+			if required {
+				return err
+			}
+			return nil
+		}
+		files = append(files, configFile{Path: path, Lines: lines})
+
+		for _, line := range lines {
+			matches := includeRegex.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			pattern := expandIncludePath(matches[1], filepath.Dir(path))
+			included, err := filepath.Glob(pattern)
+			if err != nil {
+				continue
+			}
+			sort.Strings(included)
+			for _, includedPath := range included {
+				if err := load(includedPath, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := load(mainPath, true); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// allBindings parses every loaded file, tagging each binding with the file
+// it came from.
+func allBindings(files []configFile) []Binding {
+	return allBindingsWithDisabled(files, false)
+}
+
+func allBindingsWithDisabled(files []configFile, includeDisabled bool) []Binding {
+	var bindings []Binding
+	for _, file := range files {
+		for _, binding := range i3config.ParseLinesWithDisabled(file.Lines, includeDisabled) {
+			binding.SourceFile = file.Path
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// allLines concatenates the lines of every loaded file, for lookups such as
+// variable definitions that span the whole configuration.
+func allLines(files []configFile) []string {
+	var lines []string
+	for _, file := range files {
+		lines = append(lines, file.Lines...)
+	}
+	return lines
+}
+
+// loadBindings loads the config with its includes and returns the combined
+// lines and bindings.
+func loadBindings() ([]string, []Binding, error) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	return allLines(files), allBindings(files), nil
+}
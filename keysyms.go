@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// validModifiers lists the modifier names i3 accepts, in lower case since
+// i3 matches modifiers case-insensitively.
+var validModifiers = map[string]bool{
+	"mod1": true,
+	"mod2": true,
+	"mod3": true,
+	"mod4": true,
+	"mod5": true,
+	"shift": true,
+	"control": true,
+	"ctrl": true,
+	"lock": true,
+	"mode_switch": true,
+	"group1": true,
+	"group2": true,
+	"group3": true,
+	"group4": true,
+}
+
+// validKeysyms is the set of X keysym names accepted as the final token of a
+// bindsym key. It is not exhaustive, but covers what shows up in real configs.
+var validKeysyms = map[string]bool{}
+
+var commonKeysyms = []string{
+	"Return", "space", "Tab", "Escape", "BackSpace", "Delete", "Insert",
+	"Home", "End", "Prior", "Next", "Page_Up", "Page_Down",
+	"Left", "Right", "Up", "Down",
+	"Print", "Pause", "Break", "Sys_Req", "Scroll_Lock", "Num_Lock", "Caps_Lock", "Menu",
+	"Super_L", "Super_R", "Alt_L", "Alt_R", "Control_L", "Control_R",
+	"Shift_L", "Shift_R", "Meta_L", "Meta_R", "Hyper_L", "Hyper_R", "ISO_Level3_Shift",
+
+	"minus", "equal", "plus", "comma", "period", "slash", "backslash",
+	"semicolon", "apostrophe", "grave", "bracketleft", "bracketright",
+	"braceleft", "braceright", "parenleft", "parenright", "exclam", "at",
+	"numbersign", "dollar", "percent", "asciicircum", "ampersand", "asterisk",
+	"underscore", "colon", "quotedbl", "less", "greater", "question", "bar",
+	"asciitilde", "quoteleft", "quoteright",
+
+	"KP_Enter", "KP_Add", "KP_Subtract", "KP_Multiply", "KP_Divide", "KP_Decimal",
+	"KP_Separator", "KP_Equal", "KP_Home", "KP_End", "KP_Prior", "KP_Next",
+	"KP_Page_Up", "KP_Page_Down", "KP_Left", "KP_Right", "KP_Up", "KP_Down",
+	"KP_Begin", "KP_Insert", "KP_Delete",
+
+	"XF86AudioRaiseVolume", "XF86AudioLowerVolume", "XF86AudioMute", "XF86AudioMicMute",
+	"XF86AudioPlay", "XF86AudioPause", "XF86AudioStop", "XF86AudioNext", "XF86AudioPrev",
+	"XF86AudioRecord", "XF86AudioRewind", "XF86AudioForward", "XF86AudioMedia",
+	"XF86MonBrightnessUp", "XF86MonBrightnessDown", "XF86KbdBrightnessUp",
+	"XF86KbdBrightnessDown", "XF86KbdLightOnOff", "XF86Display", "XF86ScreenSaver",
+	"XF86Sleep", "XF86PowerOff", "XF86Suspend", "XF86Hibernate", "XF86WakeUp",
+	"XF86Calculator", "XF86Mail", "XF86HomePage", "XF86Search", "XF86Explorer",
+	"XF86WWW", "XF86Tools", "XF86Favorites", "XF86Back", "XF86Forward", "XF86Refresh",
+	"XF86TouchpadToggle", "XF86TouchpadOn", "XF86TouchpadOff", "XF86WLAN",
+	"XF86Bluetooth", "XF86Battery", "XF86Eject", "XF86Copy", "XF86Paste", "XF86Cut",
+	"XF86Launch1", "XF86Launch2", "XF86Launch3", "XF86Launch4", "XF86LaunchA", "XF86LaunchB",
+	"XF86Messenger", "XF86MyComputer", "XF86Terminal", "XF86Webcam", "XF86RFKill",
+}
+
+func init() {
+	for _, keysym := range commonKeysyms {
+		validKeysyms[keysym] = true
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		validKeysyms[string(c)] = true
+		validKeysyms[strings.ToUpper(string(c))] = true
+	}
+	for i := 0; i <= 9; i++ {
+		validKeysyms[strconv.Itoa(i)] = true
+		validKeysyms[fmt.Sprintf("KP_%d", i)] = true
+	}
+	for i := 1; i <= 35; i++ {
+		validKeysyms[fmt.Sprintf("F%d", i)] = true
+	}
+	for i := 1; i <= 9; i++ {
+		validKeysyms[fmt.Sprintf("button%d", i)] = true
+	}
+}
+
+// validateKey checks every token of a key combination and returns one
+// message per unrecognized token.
+func validateKey(key string, isCode bool) []string {
+	var problems []string
+	tokens := strings.Split(key, "+")
+
+	for i, token := range tokens {
+		if token == "" {
+			problems = append(problems, fmt.Sprintf("empty token in %s", key))
+			continue
+		}
+		if strings.HasPrefix(token, "$") {
+			continue
+		}
+
+		if i < len(tokens)-1 {
+			if !validModifiers[strings.ToLower(token)] {
+				problems = append(problems, fmt.Sprintf("unknown modifier '%s' in %s", token, key))
+			}
+			continue
+		}
+
+		if isCode {
+			if _, err := strconv.Atoi(token); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid keycode '%s' in %s", token, key))
+			}
+			continue
+		}
+
+		if validKeysyms[token] {
+			continue
+		}
+		if suggestion := suggestKeysym(token); suggestion != "" {
+			problems = append(problems, fmt.Sprintf("unknown keysym '%s' in %s (did you mean '%s'?)", token, key, suggestion))
+		} else {
+			problems = append(problems, fmt.Sprintf("unknown keysym '%s' in %s", token, key))
+		}
+	}
+	return problems
+}
+
+// canonicalModifiers maps the lower case modifier names to the
+// capitalization used in i3's documentation.
+var canonicalModifiers = map[string]string{
+	"mod1": "Mod1",
+	"mod2": "Mod2",
+	"mod3": "Mod3",
+	"mod4": "Mod4",
+	"mod5": "Mod5",
+	"shift": "Shift",
+	"control": "Control",
+	"ctrl": "Ctrl",
+	"lock": "Lock",
+	"mode_switch": "Mode_switch",
+	"group1": "Group1",
+	"group2": "Group2",
+	"group3": "Group3",
+	"group4": "Group4",
+}
+
+// canonicalKey fixes the capitalization of the modifiers and special key
+// of a bindsym key, such as mod4+return to Mod4+Return. Single letters are
+// left alone since their case matters, as are variables and tokens that
+// aren't known.
+func canonicalKey(key string) string {
+	tokens := strings.Split(key, "+")
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "$") {
+			continue
+		}
+		if i < len(tokens)-1 {
+			if canonical, ok := canonicalModifiers[strings.ToLower(token)]; ok {
+				tokens[i] = canonical
+			}
+			continue
+		}
+		if !validKeysyms[token] {
+			if suggestion := suggestKeysym(token); suggestion != "" {
+				tokens[i] = suggestion
+			}
+		}
+	}
+	return strings.Join(tokens, "+")
+}
+
+// suggestKeysym returns the keysym that matches token case-insensitively,
+// or "" if there is none.
+func suggestKeysym(token string) string {
+	for keysym := range validKeysyms {
+		if strings.EqualFold(keysym, token) && len(keysym) > 1 {
+			return keysym
+		}
+	}
+	return ""
+}
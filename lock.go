@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is how often a busy lock is retried.
+const lockPollInterval = 100 * time.Millisecond
+
+// configLock is the open lock file while this process holds the lock. It is
+// kept open until the process exits, which releases the lock, or until
+// unlockConfig.
+var configLock *os.File
+
+// lockFilePath returns the lock file for a config, a hidden file next to it.
+// The config itself can't carry the lock since writes replace it by rename.
+func lockFilePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// lockConfig takes an exclusive advisory lock for the config so that the
+// read-modify-write of concurrent mutating commands is serialized. It waits
+// up to timeout for another i3-bind to finish.
+func lockConfig(path string, timeout time.Duration) error {
+	if configLock != nil || path == "-" {
+		return nil
+	}
+
+	file, err := os.OpenFile(lockFilePath(path), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// No config directory, so nothing to protect; the command
+			// reports the missing config itself.
+			return nil
+		}
+		return fmt.Errorf("failed to open lock file: %v", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			configLock = file
+			return nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			file.Close()
+			return fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return fmt.Errorf("timed out after %s waiting for another i3-bind to finish with %s (lock file %s)", timeout, path, lockFilePath(path))
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// unlockConfig releases the lock taken by lockConfig, for interactive mode,
+// which must not hold it while waiting for input.
+func unlockConfig() {
+	if configLock == nil {
+		return
+	}
+	syscall.Flock(int(configLock.Fd()), syscall.LOCK_UN)
+	configLock.Close()
+	configLock = nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -10,34 +11,227 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
-	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"i3-bind/pkg/i3config"
 )
 
 const (
 	VERSION = "1.0.0"
+
+	// mutatesAnnotation marks the commands that modify the config.
+	mutatesAnnotation = "mutates"
 )
 
 var (
 	configPath string
 	noColor bool
+	applyChanges bool
+	quiet bool
+	jsonOutput bool
+	outputPath string
+	maxBackups int
+	backupDir string
+	addMode string
+	addFile string
+	addAfter string
+	addBefore string
+	moveAfter string
+	moveBefore string
+	strictKeys bool
+	restoreList bool
+	listFormat string
+	listGroupBySection bool
+	listFilter bindingFilter
+	countFilter bindingFilter
+	listSort string
+	listReverse bool
+	listLimit int
+	expandVars bool
+	listAll bool
+	noPager bool
+	listUnbound bool
+	addTemplate string
+	addSection string
+	addIndent string
+	addNoNormalize bool
+	changesSince string
+	sideBySide bool
+	ignoreExecFlags bool
+	validateExec bool
+	exportFormat string
+	statsFormat string
+	fzfHeight string
+	fzfLayout string
+	fzfPreview string
+	pickerName string
+	assumeYes bool
+	removeAction string
+	removeMode string
+	removeAll bool
+	removeBlock bool
+	removeRelease bool
+	swayMode bool
+	findCount bool
+	verbose bool
+	commentAction string
+	commentAll bool
+	keepComment bool
+	commentInline bool
+	commentAbove bool
+	commentClear bool
+	targetLine string
+	lockTimeout time.Duration
+	findFiles bool
+	findLimit int
+	findKeyOnly bool
+	findActionOnly bool
+	findCommentOnly bool
+	findAfter int
+	findBefore int
+	findContext int
+
+	// fileFormats records the line ending and trailing newline of every
+	// config file as it was read, so writeConfigFile can reproduce them.
+	fileFormats = make(map[string]i3config.Format)
 
 	keyColor = color.New(color.FgCyan, color.Bold)
 	actionColor = color.New(color.FgGreen)
 	commentColor = color.New(color.FgYellow)
 	errorColor = color.New(color.FgRed, color.Bold)
 	successColor = color.New(color.FgGreen, color.Bold)
+	codeColor = color.New(color.FgMagenta)
+	modeColor = color.New(color.FgBlue, color.Bold)
+	disabledColor = color.New(color.Faint)
+	warningColor = color.New(color.FgYellow, color.Bold)
 )
 
-type Binding struct {
-	Key string
-	Action string
-	Comment string
-	Line int
-	Raw string
+// bindingFilter narrows a set of bindings by modifier combination, action
+// prefix and mode. Empty fields match everything.
+type bindingFilter struct {
+	Modifier string
+	ActionPrefix string
+	Mode string
+}
+
+// Binding is a keybinding parsed from the config.
+type Binding = i3config.Binding
+
+// addFilterFlags registers the flags that populate a bindingFilter.
+func addFilterFlags(cmd *cobra.Command, filter *bindingFilter) {
+	cmd.Flags().StringVar(&filter.Modifier, "modifier", "", "Only show bindings using all of these modifiers (e.g. '$mod+shift')")
+	cmd.Flags().StringVar(&filter.ActionPrefix, "action-prefix", "", "Only show bindings whose action starts with this text (e.g. exec)")
+	cmd.Flags().StringVar(&filter.Mode, "mode", "", "Only show bindings in this mode ('default' for global bindings)")
+}
+
+// xdgConfigHome returns $XDG_CONFIG_HOME, or ~/.config when it is unset,
+// empty or relative, which the XDG spec says to ignore.
+func xdgConfigHome(home string) string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(configHome) {
+		return configHome
+	}
+	return filepath.Join(home, ".config")
+}
+
+// configCandidates returns the config paths i3 or sway try, in the order
+// they try them.
+func configCandidates(home string, wm string) []string {
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+
+	candidates := []string{filepath.Join(xdgConfigHome(home), wm, "config")}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if filepath.IsAbs(dir) {
+			candidates = append(candidates, filepath.Join(dir, wm, "config"))
+		}
+	}
+	return append(candidates,
+		filepath.Join(home, "."+wm, "config"),
+		filepath.Join("/etc", wm, "config"),
+	)
+}
+
+// defaultConfigPath returns the first existing config in i3's search order,
+// or sway's with --sway. When there is no i3 config but a sway one exists,
+// the sway config is used. If nothing exists, the XDG path is returned so
+// the error names the usual location.
+func defaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+	if swayMode {
+		candidates = configCandidates(home, "sway")
+	} else {
+		candidates = append(configCandidates(home, "i3"), configCandidates(home, "sway")...)
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return candidates[0], nil
+}
+
+// completeKeys suggests the keys bound in the config for the first
+// argument of commands that target an existing binding.
+func completeKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeBindingKeys(args, false)
+}
+
+// completeDisabledKeys suggests the keys of disabled bindings.
+func completeDisabledKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeBindingKeys(args, true)
+}
+
+func completeBindingKeys(args []string, disabled bool) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if configPath == "" {
+		path, err := defaultConfigPath()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		configPath = path
+	}
+
+	files, err := loadConfigFiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var bindings []Binding
+	for _, binding := range allBindingsWithDisabled(files, disabled) {
+		if binding.Disabled == disabled {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, binding := range bindings {
+		if seen[binding.Key] {
+			continue
+		}
+		seen[binding.Key] = true
+		if binding.Action != "" {
+			keys = append(keys, binding.Key+"\t"+binding.Action)
+		} else {
+			keys = append(keys, binding.Key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
 }
 
 func main() {
@@ -46,50 +240,170 @@ func main() {
 		Short: "A CLI/TUI utility to manage i3 window manager keybindings",
 		Version: VERSION,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			if noColor {
+			if err := loadSettings(cmd); err != nil {
+				fatalf(exitError, "%v", err)
+			}
+			if noColor || jsonOutput {
 				color.NoColor = true
 			}
+			if jsonOutput {
+				cmd.Root().SilenceErrors = true
+				cmd.Root().SilenceUsage = true
+			}
 			if configPath == "" {
-				home, err := os.UserHomeDir()
+				path, err := defaultConfigPath()
 				if err != nil {
 					log.Fatal("Cannot datermine home directory")
 				}
-				configPath = filepath.Join(home, ".config", "i3", "config")
+				configPath = path
+			}
+			if cmd.Annotations[mutatesAnnotation] == "true" {
+				if err := lockConfig(configPath, lockTimeout); err != nil {
+					fatalf(exitError, "%v", err)
+				}
 			}
 		},
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to i3 config file (default: ~/.config/i3/config)")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to i3 config file, or - to read it from stdin (default: the first config found in i3's search order, starting with $XDG_CONFIG_HOME/i3/config)")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 10*time.Second, "How long to wait for another i3-bind modifying the same config")
+	rootCmd.PersistentFlags().IntVar(&maxBackups, "max-backups", 10, "Number of timestamped backups to keep (0 keeps all)")
+	rootCmd.PersistentFlags().BoolVar(&noBackup, "no-backup", false, "Don't back up the config before modifying it, e.g. when it is kept in git")
+	rootCmd.PersistentFlags().StringVar(&backupDir, "backup-dir", "", "Directory for backups instead of the config's directory (default: $I3_BIND_BACKUP_DIR)")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Print the backups created, the lines changed and the files written to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress success and informational messages; errors are still printed to stderr")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print a JSON result object {status, message, data} on stdout, including for errors")
+	rootCmd.PersistentFlags().BoolVar(&applyChanges, "apply", false, "Reload i3 (or sway) after modifying the config")
+	rootCmd.PersistentFlags().BoolVar(&swayMode, "sway", false, "Use sway's defaults: $XDG_CONFIG_HOME/sway/config, swaymsg for --apply and sway -C for check")
+	rootCmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "Write the modified config to this file and leave the config untouched")
 
 	var addCmd = &cobra.Command{
 		Use: "add [key] [action...]",
 		Short: "Add a new keybinding",
 		Long: "Add a new keybinding to the i3 config file",
-		Example: `  i3-bind add mod4+Enter exec alacritty
-  i3-bind add mod4+d exec dmenu_run
+		Example: `  i3-bind add mod4+Enter 'exec alacritty'
+  i3-bind add mod4+d 'exec dmenu_run'
   i3-bind add mod4+shift+q kill
-  i3-bind add '$mod+shift+k' keepassxc`,
-		Args: cobra.MinimumNArgs(2),
+  i3-bind add '$mod+shift+k' keepassxc
+  i3-bind add --mode resize Left 'resize shrink width 10 px'
+  i3-bind add --after '$mod+Return' '$mod+shift+Return' 'exec kitty'
+  i3-bind add '$mod+b' --template browser
+  i3-bind add '$mod+g' 'exec gimp' --section Applications`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if addTemplate != "" {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
 		Run: addBinding,
 	}
+	addCmd.Flags().StringVarP(&addMode, "mode", "m", "", "Add the keybinding inside the named mode block")
+	addCmd.Flags().StringVar(&addFile, "file", "", "Add the keybinding to this included file instead of the main config")
+	addCmd.Flags().StringVar(&addAfter, "after", "", "Insert the keybinding right after the binding for this key")
+	addCmd.Flags().StringVarP(&addTemplate, "template", "t", "", "Use the action of this template from ~/.config/i3-bind/templates.toml (built in: term, browser, dmenu, rofi, lock)")
+	addCmd.Flags().StringVar(&addBefore, "before", "", "Insert the keybinding right before the binding for this key")
+	addCmd.Flags().StringVar(&addIndent, "indent", "", "Indent the new line with this many spaces, or tab, instead of matching the bindings around it")
+	addCmd.Flags().StringVar(&addSection, "section", "", "Add the keybinding under this section header comment (e.g. Applications for '# Applications:'), creating it if needed")
+	addCmd.MarkFlagsMutuallyExclusive("after", "before", "section")
+	addCmd.MarkFlagsMutuallyExclusive("mode", "section")
+	addCmd.Flags().BoolVar(&addNoNormalize, "no-normalize", false, "Add the key exactly as given instead of fixing the case of modifiers and special keys (mod4+return to Mod4+Return)")
+	addCmd.Flags().BoolVar(&strictKeys, "strict", false, "Refuse to add keys with unrecognized keysyms or modifiers, or $variables that are never set")
 
 	var removeCmd = &cobra.Command{
 		Use: "remove [key]",
 		Short: "Remove a keybinding",
-		Long: "Remove a keybinding from the i3 config file",
+		Long: "Remove a keybinding from the i3 config file. With --action, remove every binding whose action contains the given text instead, asking for confirmation when more than one matches. With --mode and --all, remove every binding in a mode, and the mode block once it is empty.",
 		Example: `  i3-bind remove mod4+q
-  i3-bind remove mod4+Enter`,
-		Args: cobra.ExactArgs(1),
+  i3-bind remove mod4+Enter
+  i3-bind remove --action "exec oldscript"
+  i3-bind remove --line 42
+  i3-bind remove --mode screenshot --all
+  i3-bind remove --mode screenshot --all --block
+  i3-bind remove --release '$mod+x'`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if removeAction != "" || targetLine != "" || removeMode != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Run: removeBinding,
+		ValidArgsFunction: completeKeys,
+	}
+	removeCmd.Flags().StringVar(&removeAction, "action", "", "Remove the bindings whose action contains this text instead of a key")
+	removeCmd.Flags().StringVar(&targetLine, "line", "", "Remove the binding at this line of the config ([FILE:]N) instead of a key")
+	removeCmd.Flags().StringVarP(&removeMode, "mode", "m", "", "Remove the bindings in this mode instead of a key (requires --all)")
+	removeCmd.Flags().BoolVar(&removeAll, "all", false, "With --mode, remove every binding in the mode")
+	removeCmd.Flags().BoolVar(&removeBlock, "block", false, "With --mode, also remove the mode block even if other lines are left in it")
+	removeCmd.Flags().BoolVar(&removeRelease, "release", false, "Only remove the --release binding of the key; --release=false removes only the one without it")
+	removeCmd.MarkFlagsRequiredTogether("mode", "all")
+	removeCmd.MarkFlagsMutuallyExclusive("action", "line", "mode")
+	removeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Remove without asking for confirmation")
+	removeCmd.Flags().BoolVar(&keepComment, "keep-comment", false, "Keep the comment line above the binding instead of removing it too")
+
+	var swapCmd = &cobra.Command{
+		Use: "swap [key1] [key2]",
+		Short: "Exchange the actions of two keybindings",
+		Long: "Exchange the actions of two keybindings, leaving their keys, positions and comments in place",
+		Example: `  i3-bind swap '$mod+j' '$mod+k'`,
+		Args: cobra.ExactArgs(2),
+		Run: swapBindings,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeKeys(cmd, nil, toComplete)
+		},
+	}
+
+	var moveCmd = &cobra.Command{
+		Use: "move [key]",
+		Short: "Move a keybinding next to another one",
+		Long: "Move a keybinding, together with its comment line, right after or before another keybinding without changing its content",
+		Example: `  i3-bind move '$mod+shift+Return' --after '$mod+Return'
+  i3-bind move '$mod+space' --before '$mod+d'`,
+		Args: cobra.ExactArgs(1),
+		Run: moveBinding,
+		ValidArgsFunction: completeKeys,
 	}
+	moveCmd.Flags().StringVar(&moveAfter, "after", "", "Move the keybinding right after the binding for this key")
+	moveCmd.Flags().StringVar(&moveBefore, "before", "", "Move the keybinding right before the binding for this key")
+	moveCmd.MarkFlagsMutuallyExclusive("after", "before")
+	moveCmd.MarkFlagsOneRequired("after", "before")
 
 	var listCmd = &cobra.Command{
 		Use: "list",
 		Short: "List all keybindings",
 		Long: "List all keybinding in the i3 config file with syntax highlighting",
+		Example: `  i3-bind list
+  i3-bind list --format json
+  i3-bind list --format tsv | cut -f1,2
+  i3-bind list --modifier '$mod+shift'
+  i3-bind list --action-prefix exec --mode default
+  i3-bind list --sort line --reverse`,
 		Run: listBindings,
 	}
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", "text", "Output format: text, table, json or tsv")
+	listCmd.Flags().BoolVar(&listGroupBySection, "group-by-section", false, "Group bindings under the section header comments (ending in ':') above them instead of by mode")
+	listCmd.Flags().BoolVar(&listUnbound, "unbound", false, "Show common i3 actions that have no keybinding yet")
+	listCmd.Flags().BoolVar(&noPager, "no-pager", false, "Print the list directly instead of through $PAGER when it doesn't fit on the screen")
+	listCmd.Flags().StringVarP(&listSort, "sort", "s", "key", "Sort order: key, line or action")
+	listCmd.Flags().BoolVarP(&listReverse, "reverse", "r", false, "Reverse the sort order")
+	listCmd.Flags().IntVarP(&listLimit, "limit", "n", 0, "Show only the first N keybindings after sorting (0 shows all)")
+	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "Include bindings switched off with 'i3-bind disable'")
+	listCmd.Flags().BoolVarP(&expandVars, "expand", "e", false, "Substitute $variables defined with set before filtering and display")
+	addFilterFlags(listCmd, &listFilter)
+
+	var exportCmd = &cobra.Command{
+		Use: "export",
+		Short: "Export keybindings as a Markdown cheatsheet or JSON",
+		Long: "Export all keybindings to stdout as a Markdown table per mode or as JSON",
+		Example: `  i3-bind export > keybindings.md
+  i3-bind export --format json`,
+		Args: cobra.NoArgs,
+		Run: exportBindings,
+	}
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "markdown", "Output format: markdown or json")
 
 	var findCmd = &cobra.Command{
 		Use: "find [search_term]",
@@ -98,433 +412,2538 @@ func main() {
 		Example: `  i3-bind find firefox
   i3-bind finx exec
   i3-bind find mod4+shift
-  i3-bind find '$mod+return'`,
+  i3-bind find '$mod+return'
+  i3-bind find --expand Mod4
+  i3-bind find exec --count
+  i3-bind find exec --files
+  i3-bind find term --comment-only`,
 		Args: cobra.ExactArgs(1),
 		Run: findBindings,
 	}
+	findCmd.Flags().BoolVarP(&expandVars, "expand", "e", false, "Substitute $variables defined with set before matching and display")
+	findCmd.Flags().BoolVar(&findCount, "count", false, "Print only the number of matching keybindings")
+	findCmd.Flags().BoolVar(&findFiles, "files", false, "Print only the distinct config files containing matches")
+	findCmd.MarkFlagsMutuallyExclusive("count", "files")
+	findCmd.Flags().IntVarP(&findLimit, "limit", "n", 0, "Show only the first N matches, in config order (0 shows all)")
+	findCmd.Flags().BoolVar(&findKeyOnly, "key-only", false, "Search only the keys (combine with --action-only or --comment-only to search several fields)")
+	findCmd.Flags().BoolVar(&findActionOnly, "action-only", false, "Search only the actions")
+	findCmd.Flags().BoolVar(&findCommentOnly, "comment-only", false, "Search only the comments")
+	findCmd.Flags().IntVarP(&findAfter, "after-context", "A", 0, "Print N config lines after each match")
+	findCmd.Flags().IntVarP(&findBefore, "before-context", "B", 0, "Print N config lines before each match")
+	findCmd.Flags().IntVarP(&findContext, "context", "C", 0, "Print N config lines before and after each match")
 
 	var commentCmd = &cobra.Command{
 		Use: "comment [key] [comment]",
-		Short: "Add or update comment for a keybinding",
-		Long: "Add or update a comment for an existing keybinding",
+		Short: "Add, update or clear the comment of a keybinding",
+		Long: "Add or update a comment for an existing keybinding. With --clear, remove its comment instead, whether inline or on the line above.",
 		Example: `  i3-bind comment mod4+r "restart i3"
   i3-bind comment mod4+shift+3 "exit i3"
-  i3-bind comment "$mod+return" "run terminal"`,
-		Args: cobra.ExactArgs(2),
+  i3-bind comment "$mod+return" "run terminal"
+  i3-bind comment --action "exec firefox" "open browser"
+  i3-bind comment --line 42 "open browser"
+  i3-bind comment --clear mod4+r`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			n := 2
+			if commentAction != "" || targetLine != "" {
+				n--
+			}
+			if commentClear {
+				n--
+			}
+			return cobra.ExactArgs(n)(cmd, args)
+		},
 		Run: commentBinding,
+		ValidArgsFunction: completeKeys,
+	}
+	commentCmd.Flags().StringVar(&commentAction, "action", "", "Comment the binding whose action contains this text instead of a key")
+	commentCmd.Flags().StringVar(&targetLine, "line", "", "Comment the binding at this line of the config ([FILE:]N) instead of a key")
+	commentCmd.MarkFlagsMutuallyExclusive("action", "line")
+	commentCmd.Flags().BoolVar(&commentAll, "all", false, "With --action, comment every matching binding without asking")
+	commentCmd.Flags().BoolVar(&commentInline, "inline", false, "Put the comment at the end of the binding line (default: $I3_BIND_COMMENT_STYLE)")
+	commentCmd.Flags().BoolVar(&commentAbove, "above", false, "Put the comment on its own line above the binding")
+	commentCmd.Flags().BoolVar(&commentClear, "clear", false, "Remove the comment of the binding instead of setting one")
+	commentCmd.MarkFlagsMutuallyExclusive("inline", "above", "clear")
+
+	var editCmd = &cobra.Command{
+		Use: "edit [key] [action...]",
+		Short: "Change the action of a keybinding",
+		Long: "Change the action of an existing keybinding, keeping its key and comment",
+		Example: `  i3-bind edit '$mod+Return' 'exec kitty'
+  i3-bind edit mod4+d 'exec rofi -show drun'
+  i3-bind edit --line 42 'exec kitty'`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if targetLine != "" {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+		Run: editBinding,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeKeys(cmd, args, toComplete)
+		},
+	}
+	editCmd.Flags().StringVar(&targetLine, "line", "", "Edit the binding at this line of the config ([FILE:]N) instead of a key")
+
+	var renameCmd = &cobra.Command{
+		Use: "rename [oldkey] [newkey]",
+		Short: "Change the key of a keybinding",
+		Long: "Change the key of an existing keybinding, keeping its action and comment",
+		Example: `  i3-bind rename '$mod+Return' '$mod+shift+Return'
+  i3-bind rename mod4+d mod4+space`,
+		Args: cobra.ExactArgs(2),
+		Run: renameBinding,
+		ValidArgsFunction: completeKeys,
 	}
 
-	var interactiveCmd = &cobra.Command{
-		Use: "interactive",
-		Short: "Launch interactive TUI mode",
-		Long: "Launch an interactive terminal user interface for managing keybindings",
-		Aliases: []string{"tui", "menu"},
-		Run: interactiveMode,
+	var disableCmd = &cobra.Command{
+		Use: "disable [key]",
+		Short: "Temporarily disable a keybinding",
+		Long: "Comment out a keybinding with a [disabled] marker so it can be re-enabled later",
+		Example: `  i3-bind disable '$mod+d'`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runToggle(args[0], false)
+		},
+		ValidArgsFunction: completeKeys,
 	}
 
-	rootCmd.AddCommand(addCmd, removeCmd, listCmd, findCmd, commentCmd, interactiveCmd)
+	var enableCmd = &cobra.Command{
+		Use: "enable [key]",
+		Short: "Re-enable a disabled keybinding",
+		Long: "Re-enable a keybinding previously switched off with 'i3-bind disable'",
+		Example: `  i3-bind enable '$mod+d'`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runToggle(args[0], true)
+		},
+		ValidArgsFunction: completeDisabledKeys,
+	}
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	var describeCmd = &cobra.Command{
+		Use: "describe [key]",
+		Short: "Explain what a keybinding does in plain words",
+		Long: "Look up a keybinding and explain its action for people new to i3, such as exec, focus, workspace and mode commands",
+		Example: `  i3-bind describe '$mod+Return'
+  i3-bind describe '$mod+r'`,
+		Args: cobra.ExactArgs(1),
+		Run: describeBinding,
+		ValidArgsFunction: completeKeys,
 	}
-}
 
-func readConfig() ([]string, error) {
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("i3 config file not found at %s", configPath)
+	var validateCmd = &cobra.Command{
+		Use: "validate",
+		Short: "Check keybindings for unknown keysyms and modifiers",
+		Long: "Check every keybinding in the i3 config file for unrecognized keysyms and modifiers, and with --exec for programs missing from $PATH",
+		Args: cobra.NoArgs,
+		Run: validateBindings,
 	}
-	
-	content, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %v",err)
+	validateCmd.Flags().BoolVar(&validateExec, "exec", false, "Also check that the programs run by exec are in $PATH")
+
+	var checkCmd = &cobra.Command{
+		Use: "check",
+		Short: "Check the whole config with i3's own parser",
+		Long: "Run 'i3 -C' (or 'sway -C' for sway configs) against the config file and report syntax errors with their line numbers",
+		Args: cobra.NoArgs,
+		Run: checkConfig,
 	}
-	return strings.Split(string(content), "\n"),nil
-}
 
-func writeConfig(lines []string) error {
-	content := strings.Join(lines, "\n")
+	var whichCmd = &cobra.Command{
+		Use: "which [key]",
+		Short: "Show the file and line where a key is bound",
+		Long: "Print the absolute file path and line number of every definition of a key, across included files and modes",
+		Example: `  i3-bind which '$mod+Return'
+  i3-bind which Mod4+Return # also finds $mod+Return when $mod is Mod4`,
+		Args: cobra.ExactArgs(1),
+		Run: whichBinding,
+		ValidArgsFunction: completeKeys,
+	}
 
-	backupPath := configPath + ".backup"
-	if err := ioutil.WriteFile(backupPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to create backup: %v", err)
+	var changesCmd = &cobra.Command{
+		Use: "changes",
+		Short: "Show keybinding changes made recently",
+		Long: "Compare the config as it was a while ago, taken from its backups, with the current config and show the keybindings added, removed and changed since then",
+		Example: `  i3-bind changes --since 1h
+  i3-bind changes --since 2d`,
+		Args: cobra.NoArgs,
+		Run: showChanges,
+	}
+	changesCmd.Flags().StringVar(&changesSince, "since", "24h", "How far back to look, e.g. 30m, 1h or 2d")
+	changesCmd.Flags().BoolVar(&sideBySide, "side-by-side", false, "Show the old and new bindings in two aligned columns")
+	changesCmd.Flags().BoolVar(&ignoreExecFlags, "ignore-exec-flags", false, "Treat exec actions that differ only in --no-startup-id as the same")
+
+	var pathCmd = &cobra.Command{
+		Use: "path",
+		Short: "Print the config file i3-bind uses",
+		Long: "Print the absolute path of the config file i3-bind resolved to, whether it exists and the included files it reads along with it",
+		Example: `  i3-bind path
+  i3-bind path --sway
+  $EDITOR "$(i3-bind -q path)"`,
+		Args: cobra.NoArgs,
+		Run: showConfigPath,
 	}
 
-	if err := ioutil.WriteFile(configPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	var watchCmd = &cobra.Command{
+		Use: "watch",
+		Short: "Reload i3 whenever the config changes and passes i3 -C",
+		Long: "Watch the config and its included files, and each time one of them changes check the config with i3 -C (sway -C for sway configs). A config that passes is reloaded with i3-msg reload; otherwise the errors are printed and the running config is left alone. Runs until interrupted.",
+		Example: `  i3-bind watch
+  i3-bind -c ~/.config/sway/config watch`,
+		Args: cobra.NoArgs,
+		Run: watchConfig,
 	}
 
-	return nil
-}
+	var graphCmd = &cobra.Command{
+		Use: "graph",
+		Short: "Print the mode transitions as a Graphviz graph",
+		Long: "Print a Graphviz DOT graph of the modes in the config. Each mode is a node, and each edge is labeled with the keys that switch from one mode to the other.",
+		Example: `  i3-bind graph | dot -Tsvg -o modes.svg
+  i3-bind graph --expand`,
+		Args: cobra.NoArgs,
+		Run: printModeGraph,
+	}
+	graphCmd.Flags().BoolVarP(&expandVars, "expand", "e", false, "Substitute $variables defined with set in the edge labels")
+
+	var diffCmd = &cobra.Command{
+		Use: "diff [fileA] [fileB]",
+		Short: "Compare the keybindings of two config files",
+		Long: "Report keybindings only in one of two configs and keys bound in both with a different action or comment. Keys are matched per mode, ignoring modifier order and case. Exits with a non-zero status when the configs differ.",
+		Example: `  i3-bind diff ~/.config/i3/config.work ~/.config/i3/config.home
+  i3-bind diff --side-by-side ~/.config/i3/config.work ~/.config/i3/config.home`,
+		Args: cobra.ExactArgs(2),
+		Run: diffConfigs,
+	}
+	diffCmd.Flags().BoolVar(&sideBySide, "side-by-side", false, "Show the bindings of both configs in two aligned columns")
+	diffCmd.Flags().BoolVar(&ignoreExecFlags, "ignore-exec-flags", false, "Treat exec actions that differ only in --no-startup-id as the same")
+
+	var statsCmd = &cobra.Command{
+		Use: "stats",
+		Short: "Show a summary of the keybindings",
+		Long: "Show binding counts by modifier, action type, comments and mode",
+		Example: `  i3-bind stats
+  i3-bind stats --format json | jq .byModifier`,
+		Args: cobra.NoArgs,
+		Run: showStats,
+	}
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "text", "Output format: text or json")
+
+	var countCmd = &cobra.Command{
+		Use: "count",
+		Short: "Print the number of keybindings",
+		Long: "Print the number of keybindings as a bare integer, optionally filtered like 'list'",
+		Example: `  i3-bind count
+  i3-bind count --action-prefix exec
+  i3-bind count --mode resize`,
+		Args: cobra.NoArgs,
+		Run: countBindings,
+	}
+	countCmd.Flags().BoolVarP(&expandVars, "expand", "e", false, "Substitute $variables defined with set before filtering")
+	addFilterFlags(countCmd, &countFilter)
+
+	var conflictsCmd = &cobra.Command{
+		Use: "conflicts",
+		Short: "Report keys that are bound more than once",
+		Long: "Report keys bound more than once in the same mode, ignoring modifier order and case. Exits with a non-zero status when conflicts are found.",
+		Args: cobra.NoArgs,
+		Run: listConflicts,
+	}
 
-func parseBindings(lines []string) []Binding {
-	var bindings []Binding
-	bindRegex := regexp.MustCompile(`^\s*bindsym\s+([^\s]+)\s+(.+?)(?:\s*#\s*(.*))?$`)
+	var importCmd = &cobra.Command{
+		Use: "import [file]",
+		Short: "Bulk-apply keybindings from a YAML or JSON file",
+		Long: `Apply a list of {key, action, comment, mode} entries from a YAML or JSON file.
 
-	for i, line := range lines {
-		matches := bindRegex.FindStringSubmatch(line)
-		if matches != nil {
-			comment := strings.TrimSpace(matches[3])
-
-			if comment == "" && i > 0 {
-				previousLine := strings.TrimSpace(lines[i-1])
-				if strings.HasPrefix(previousLine, "#") {
-					trimmed := strings.TrimSpace(strings.TrimPrefix(previousLine, "#"))
-					
-					if !strings.HasSuffix(trimmed, ":") {
-						comment = trimmed
-					}
-				}
+Missing bindings are added and existing ones have their action and comment
+updated. With --prune, bindings that are not in the file are removed.`,
+		Example: `  i3-bind import bindings.yaml --dry-run
+  i3-bind import bindings.json --prune
+  i3-bind import bindings.yaml --prune --dry-run --side-by-side`,
+		Args: cobra.ExactArgs(1),
+		Run: importBindings,
+	}
+	importCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Show what would change without writing the config")
+	importCmd.Flags().BoolVar(&importPrune, "prune", false, "Remove bindings that are not in the import file")
+	importCmd.Flags().BoolVar(&sideBySide, "side-by-side", false, "Show the changes as current and imported bindings in two aligned columns")
+	importCmd.Flags().BoolVar(&ignoreExecFlags, "ignore-exec-flags", false, "Treat exec actions that differ only in --no-startup-id as the same")
+
+	var restoreCmd = &cobra.Command{
+		Use: "restore [backup]",
+		Short: "Restore the config from a backup",
+		Long: "Restore the i3 config file from a timestamped backup, the most recent one by default",
+		Example: `  i3-bind restore --list
+  i3-bind restore
+  i3-bind restore 20250101-120000
+  i3-bind restore --yes`,
+		Args: cobra.MaximumNArgs(1),
+		Run: restoreBackup,
+	}
+	restoreCmd.Flags().BoolVarP(&restoreList, "list", "l", false, "List available backups, newest first")
+	restoreCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Restore without asking for confirmation")
+
+	var undoCmd = &cobra.Command{
+		Use: "undo",
+		Short: "Undo the last change",
+		Long: "Restore the config from the most recent backup and delete that backup, so repeated undos step back through earlier changes",
+		Example: `  i3-bind undo
+  i3-bind undo --yes`,
+		Args: cobra.NoArgs,
+		Run: undoChange,
+	}
+	undoCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Undo without asking for confirmation")
+
+	var completionCmd = &cobra.Command{
+		Use: "completion [bash|zsh|fish|powershell]",
+		Short: "Generate shell completion scripts",
+		Long: `Generate a shell completion script for i3-bind.
+
+Commands that take an existing key (remove, edit, rename, comment, disable)
+complete the keys bound in your config.`,
+		Example: `  i3-bind completion bash > /etc/bash_completion.d/i3-bind
+  i3-bind completion zsh > "${fpath[1]}/_i3-bind"
+  i3-bind completion fish > ~/.config/fish/completions/i3-bind.fish`,
+		Args: cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch args[0] {
+			case "bash":
+				err = cmd.Root().GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				err = cmd.Root().GenZshCompletion(os.Stdout)
+			case "fish":
+				err = cmd.Root().GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				err = cmd.Root().GenPowerShellCompletionWithDesc(os.Stdout)
+			default:
+				err = fmt.Errorf("unsupported shell %s (expected bash, zsh, fish or powershell)", args[0])
 			}
-			binding := Binding{
-				Key: matches[1],
-				Action: strings.TrimSpace(matches[2]),
-				Comment: comment,
-				Line: i+1,
-				Raw: line,
+			if err != nil {
+				fatalf(exitCode(err), "%v", err)
 			}
-			bindings = append(bindings, binding)
-		}
+		},
 	}
-	return bindings
-}
 
-func insertLine(lines []string, index int, newLine string) []string {
-	return append(lines[:index], append([]string{newLine}, lines[index:]...)...)
-}
-
-func addBinding(cmd *cobra.Command, args []string) {
-	key := args[0]
-	action := strings.Join(args[1:], " ")
-
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n",err)
-		os.Exit(1)
+	var interactiveCmd = &cobra.Command{
+		Use: "interactive",
+		Short: "Launch interactive TUI mode",
+		Long: "Launch an interactive terminal user interface for managing keybindings",
+		Aliases: []string{"tui", "menu"},
+		Example: `  i3-bind interactive
+  i3-bind interactive --fzf-height 100% --fzf-layout reverse
+  I3_BIND_FZF_OPTS='--border --preview-window=right:50%' i3-bind tui
+  i3-bind interactive --picker rofi`,
+		Run: interactiveMode,
+	}
+	interactiveCmd.Flags().StringVar(&pickerName, "picker", "auto", "Selection menu: auto, fzf, rofi or dmenu")
+	interactiveCmd.Flags().StringVar(&fzfHeight, "fzf-height", "40%", "Height of the fzf picker")
+	interactiveCmd.Flags().StringVar(&fzfLayout, "fzf-layout", "", "fzf layout: default, reverse or reverse-list")
+	interactiveCmd.Flags().StringVar(&fzfPreview, "fzf-preview", "", "Preview command for fzf instead of the built-in key/action preview ({1} key, {2} action, {3} comment, {7} file:line)")
+
+	// Commands that rewrite the config hold its lock from reading it until
+	// they exit. Interactive mode takes the lock around each of its writes
+	// instead, so it never holds it while waiting for input.
+	for _, mutating := range []*cobra.Command{addCmd, removeCmd, editCmd, renameCmd, swapCmd, moveCmd, commentCmd, disableCmd, enableCmd, importCmd, restoreCmd, undoCmd} {
+		mutating.Annotations = map[string]string{mutatesAnnotation: "true"}
 	}
 
-	bindings := parseBindings(lines)
-	for _, binding := range bindings {
-		if strings.EqualFold(binding.Key, key){
-			errorColor.Printf("Error: Keybinding %s already exists\n", key)
-			fmt.Printf("Current bindig: %s -> %s\n", keyColor.Sprint(binding.Key), actionColor.Sprint(binding.Action))
-			fmt.Println("Use 'i3-bind remove' first or modify the config manually")
-			os.Exit(1)
+	rootCmd.AddCommand(addCmd, removeCmd, editCmd, renameCmd, swapCmd, moveCmd, listCmd, exportCmd, findCmd, whichCmd, describeCmd, commentCmd, disableCmd, enableCmd, validateCmd, checkCmd, conflictsCmd, diffCmd, changesCmd, pathCmd, watchCmd, graphCmd, statsCmd, countCmd, importCmd, restoreCmd, undoCmd, completionCmd, interactiveCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		if jsonOutput {
+			printResult("error", err.Error(), nil)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
 		}
+		os.Exit(exitError)
+	}
+	if jsonOutput {
+		printResult("ok", "", nil)
 	}
+}
 
-	newBinding := fmt.Sprintf("bindsym %s %s", key, action)
+// stdinConfig caches the config read from stdin with --config -, since
+// stdin can only be read once.
+var stdinConfig []byte
+
+func readConfigFile(path string) ([]string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		if stdinConfig == nil {
+			if stdinConfig, err = ioutil.ReadAll(os.Stdin); err != nil {
+				return nil, fmt.Errorf("failed to read config from stdin: %v", err)
+			}
+		}
+		content = stdinConfig
+	} else {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w at %s", errConfigMissing, path)
+		}
 
-	insertIndex := len(lines)
-	for i := len(lines) - 1; i >= 0; i-- {
-		if strings.Contains(strings.TrimSpace(lines[i]), "bindsym") {
-			insertIndex = i + 1
-			break
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v",err)
 		}
 	}
 
-	newLines := make([]string, 0, len(lines)+1)
-	newLines = append(newLines, lines[:insertIndex]...)
-	newLines = append(newLines, newBinding)
-	newLines = append(newLines, lines[insertIndex:]...)
+	lines, format := i3config.SplitLines(content)
+	fileFormats[path] = format
+	return lines, nil
+}
 
-	if err := writeConfig(newLines); err != nil {
-		errorColor.Printf("Error: %v\n", err)
-		os.Exit(1)
+func writeConfigFile(path string, lines []string) error {
+	format, ok := fileFormats[path]
+	if !ok {
+		format = i3config.DefaultFormat
 	}
-	successColor.Printf("✓ Added keybinding: %s -> %s\n", keyColor.Sprint(key), actionColor.Sprint(action))
-}
+	content := i3config.JoinLines(lines, format)
 
-func removeBinding(cmd *cobra.Command, args []string) {
-	key := args[0]
+	if verbose {
+		if before, err := readConfigFile(path); err == nil {
+			for _, change := range lineChanges(before, lines) {
+				if change.Removed {
+					printVerbose("%s:%d: - %s", path, change.Line, change.Text)
+				} else {
+					printVerbose("%s:%d: + %s", path, change.Line, change.Text)
+				}
+			}
+		}
+	}
+	return writeConfigContent(path, content)
+}
 
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n", err)
-		os.Exit(1)
+// writeConfigContent backs up the file at path and then replaces it
+// atomically with i3config.WriteFile. With --output the main config is
+// written to the output file instead.
+func writeConfigContent(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
 	}
 
-	bindings := parseBindings(lines)
-	found := false
-	var removedBinding Binding
+	if outputPath != "" {
+		if path != configPath {
+			return fmt.Errorf("--output only supports changes to the main config, not %s", path)
+		}
+		path = outputPath
+	} else if path == "-" {
+		return fmt.Errorf("cannot write a config read from stdin; use --output to write the result to a file")
+	}
 
-	for _, binding := range bindings {
-		if strings.EqualFold(binding.Key, key) {
-			found = true
-			removedBinding = binding
-			break
+	if !noBackup {
+		original, err := ioutil.ReadFile(path)
+		if err == nil {
+			backupPath, err := createBackup(path, original, mode)
+			if err != nil {
+				return fmt.Errorf("failed to create backup: %v", err)
+			}
+			printVerbose("backed up %s to %s", path, backupPath)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read config file for backup: %v", err)
 		}
 	}
 
-	if !found {
-		errorColor.Printf("Error: Keybinding %s not found\n", key)
-		os.Exit(1)
+	if err := i3config.WriteFile(path, content, mode); err != nil {
+		return err
 	}
+	printVerbose("wrote %d bytes to %s", len(content), path)
 
-	newLines := make([]string, 0, len(lines)-1)
-	bindRegex := regexp.MustCompile(`(?i)^\s*bindsym\s+` + regexp.QuoteMeta(key) + `\s+`)
+	return nil
+}
 
-	for _, line := range lines {
-		if !bindRegex.MatchString(line) {
-			newLines = append(newLines, line)
+// isSwayConfig reports whether --sway is set or the config path lives under
+// sway's config directory rather than i3's.
+func isSwayConfig() bool {
+	if swayMode {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return false
+	}
+	for _, candidate := range configCandidates(home, "sway") {
+		if strings.HasPrefix(absPath, filepath.Dir(candidate)+string(filepath.Separator)) {
+			return true
 		}
 	}
+	return false
+}
 
-	if err := writeConfig(newLines); err != nil {
-		errorColor.Printf("Error: %v\n", err)
-		os.Exit(1)
+// reloadIfRequested reloads the window manager when --apply is set. A
+// failed reload only warns since the config itself was already written.
+func reloadIfRequested() {
+	if applyChanges {
+		reloadWindowManager()
 	}
-
-	successColor.Printf("✓ Removed keybinding: %s -> %s\n", keyColor.Sprint(removedBinding.Key), actionColor.Sprint(removedBinding.Action))
 }
 
-func listBindings(cmd *cobra.Command, args []string) {
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n",err)
-		os.Exit(1)
+// reloadWindowManager reloads i3, or sway for a sway config, warning
+// instead of failing when that isn't possible.
+func reloadWindowManager() {
+	msgCmd := "i3-msg"
+	if isSwayConfig() {
+		msgCmd = "swaymsg"
 	}
 
-	bindings := parseBindings(lines)
-	if len(bindings) == 0 {
-		fmt.Println("No keybindings found in config file")
+	if _, err := exec.LookPath(msgCmd); err != nil {
+		warningColor.Fprintf(os.Stderr, "Warning: %s not found in PATH, skipping reload\n", msgCmd)
 		return
 	}
 
-	sort.Slice(bindings, func(i, j int) bool {
-		return bindings[i].Key < bindings[j].Key
-	})
+	output, err := exec.Command(msgCmd, "reload").CombinedOutput()
+	result := strings.TrimSpace(string(output))
+	if err != nil {
+		warningColor.Fprintf(os.Stderr, "Warning: %s reload failed: %v\n", msgCmd, err)
+		if result != "" {
+			fmt.Fprintln(os.Stderr, result)
+		}
+		return
+	}
 
-	fmt.Printf("Found %d keybindings in %s:\n\n", len(bindings), configPath)
+	printSuccess("✓ Reloaded config with %s reload\n", msgCmd)
+	if result != "" && !quiet && !jsonOutput {
+		fmt.Println(result)
+	}
+}
 
-	for _, binding := range bindings {
-		fmt.Printf("  %s -> %s", keyColor.Sprint(binding.Key),actionColor.Sprint(binding.Action))
-		if binding.Comment != "" {
-			fmt.Printf(" %s", commentColor.Sprintf("# %s",binding.Comment))
+// findConfigFile returns the loaded file with the given path, comparing
+// absolute paths so relative and absolute spellings match.
+func findConfigFile(files []configFile, path string) (configFile, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	for _, file := range files {
+		fileAbs, err := filepath.Abs(file.Path)
+		if err != nil {
+			fileAbs = file.Path
+		}
+		if fileAbs == absPath {
+			return file, true
 		}
-		fmt.Println()
 	}
+	return configFile{}, false
 }
 
-func findBindings(cmd *cobra.Command, args []string) {
-	searchTerm := args[0]
-
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n", err)
-		os.Exit(1)
+// formatLocation describes where a binding is defined, naming the file only
+// when it is not the main config.
+func formatLocation(binding Binding) string {
+	if binding.SourceFile == "" || binding.SourceFile == configPath {
+		return fmt.Sprintf("line %d", binding.Line)
+	}
+	path := binding.SourceFile
+	if rel, err := filepath.Rel(filepath.Dir(configPath), path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
 	}
+	return fmt.Sprintf("%s line %d", path, binding.Line)
+}
 
-	bindings := parseBindings(lines)
-	var matches []Binding
+// bindingID identifies a binding by mode, binding type and normalized key.
+func bindingID(binding Binding) string {
+	return fmt.Sprintf("%s\x00%s\x00%s", binding.Mode, binding.Type, i3config.NormalizeKey(binding.Key))
+}
 
-	searchLower := strings.ToLower(searchTerm)
-	for _, binding := range bindings {
-		if strings.Contains(strings.ToLower(binding.Key), searchLower) ||
-		   strings.Contains(strings.ToLower(binding.Action), searchLower) ||
-		   strings.Contains(strings.ToLower(binding.Comment), searchLower) {
-			matches = append(matches, binding)
-		}
+// formatKey renders a binding's key for display, marking bindcode and
+// bindgesture entries so they can be told apart from bindsym keys.
+func formatKey(binding Binding) string {
+	key := keyColor.Sprint(binding.Key)
+	if len(binding.Flags) > 0 {
+		key = codeColor.Sprint(strings.Join(binding.Flags, " ")) + " " + key
 	}
-
-	if len(matches) == 0 {
-		fmt.Printf("No keybindings found matching '%s'\n", searchTerm)
-		return
+	if binding.IsCode {
+		return fmt.Sprintf("%s %s", key, codeColor.Sprint("[code]"))
 	}
+	if binding.Type == "bindgesture" {
+		return fmt.Sprintf("%s %s", key, codeColor.Sprint("[gesture]"))
+	}
+	return key
+}
 
-	fmt.Printf("Found %d keybinding(s) matching '%s':\n\n",len(matches),searchTerm)
+// typeRank orders binding types for sorting: bindsym, then bindcode, then
+// bindgesture.
+func typeRank(binding Binding) int {
+	switch binding.Type {
+	case "bindcode":
+		return 1
+	case "bindgesture":
+		return 2
+	}
+	return 0
+}
 
-	for _, binding := range matches {
-		fmt.Printf("  %s -> %s", keyColor.Sprint(binding.Key), actionColor.Sprint(binding.Action))
-		if binding.Comment != "" {
-			fmt.Printf(" %s", commentColor.Sprintf("# %s", binding.Comment))
+// sortBindings orders bindsym entries by key first, followed by bindcode
+// entries ordered numerically by keycode and then bindgesture entries.
+func sortBindings(bindings []Binding) {
+	sort.SliceStable(bindings, func(i, j int) bool {
+		a, b := bindings[i], bindings[j]
+		if typeRank(a) != typeRank(b) {
+			return typeRank(a) < typeRank(b)
+		}
+		if a.IsCode {
+			codeA, errA := strconv.Atoi(a.Key)
+			codeB, errB := strconv.Atoi(b.Key)
+			if errA == nil && errB == nil {
+				return codeA < codeB
+			}
 		}
-		fmt.Printf(" %s\n", color.New(color.FgBlack, color.Bold).Sprintf("(line %d)", binding.Line))
+		return a.Key < b.Key
+	})
+}
+
+// modeLabel returns the name shown for a binding's mode, using i3's own
+// name for the global scope.
+func modeLabel(mode string) string {
+	if mode == "" {
+		return "default"
 	}
+	return mode
 }
 
-func commentBinding(cmd *cobra.Command, args []string) {
-	key := args[0]
-	comment := args[1]
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n",err)
-		os.Exit(1)
-	}
+// confirm asks a yes/no question on stdin, defaulting to no.
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
 
-	bindings := parseBindings(lines)
-	found := false
+// sortBindingsBy orders bindings by key, line or action, optionally in
+// reverse.
+func sortBindingsBy(bindings []Binding, field string, reverse bool) error {
+	switch field {
+	case "key":
+		sortBindings(bindings)
+	case "line":
+		sort.SliceStable(bindings, func(i, j int) bool {
+			return bindings[i].Line < bindings[j].Line
+		})
+	case "action":
+		sortBindings(bindings)
+		sort.SliceStable(bindings, func(i, j int) bool {
+			return bindings[i].Action < bindings[j].Action
+		})
+	default:
+		return fmt.Errorf("unknown sort order %s (expected key, line or action)", field)
+	}
 
-	for _, binding := range bindings {
-		if strings.EqualFold(binding.Key, key) {
-			found = true
-			break
+	if reverse {
+		for i, j := 0, len(bindings)-1; i < j; i, j = i+1, j-1 {
+			bindings[i], bindings[j] = bindings[j], bindings[i]
 		}
 	}
+	return nil
+}
 
-	if !found {
-		errorColor.Printf("Error: Keybinding %s not found\n", key)
-		os.Exit(1)
+// sectionFile returns the first loaded file with a section header named
+// section.
+func sectionFile(files []configFile, section string) (string, bool) {
+	for _, file := range files {
+		if sectionIndex(file.Lines, section) >= 0 {
+			return file.Path, true
+		}
 	}
+	return "", false
+}
 
-	bindRegex := regexp.MustCompile(`(?i)^\s*bindsym\s+` + regexp.QuoteMeta(key) + `\s+(.+?)(?:\s*#.*)?$`)
-
+// sectionIndex returns the 0-based index of the first section header named
+// section, matched case-insensitively and with or without the trailing
+// ":", or -1 if there is none.
+func sectionIndex(lines []string, section string) int {
+	name := strings.TrimSuffix(strings.TrimSpace(section), ":")
 	for i, line := range lines {
-		if bindRegex.MatchString(line) {
-			if i > 0 {
-				prevLine := strings.TrimSpace(lines[i-1])
-				if strings.HasPrefix(prevLine, "#") {
-					trimmed := strings.TrimSpace(strings.TrimPrefix(prevLine, "#"))
-					if strings.HasSuffix(trimmed, ":") {
-						lines = insertLine(lines, i, "# " + comment)
-					} else {
-						lines[i-1] = "# " + comment
-					}
-				} else {
-					lines = insertLine(lines, i, "# " + comment)
-				}
-			} else {
-				lines = insertLine(lines, i, "# "+comment)
-			}
-			break
+		if header, ok := i3config.SectionHeader(line); ok && strings.EqualFold(header, name) {
+			return i
 		}
 	}
-
-	if err := writeConfig(lines); err != nil {
-		errorColor.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
-	successColor.Printf("✓ Added comment to keybinding: %s # %s\n",keyColor.Sprint(key), commentColor.Sprint(comment))
+	return -1
 }
 
-func interactiveMode(cmd *cobra.Command, args []string){
-
-	escapePreview := func(s string) string {
-		s = strings.ReplaceAll(s, `\`, `\\`)
-		s = strings.ReplaceAll(s, `"`, `\"`)
-		s = strings.ReplaceAll(s, "$", `\$`)
-		return s
+// insertInSection inserts newLine after the last global binding of the
+// named section, or right below its header when it has none. A missing
+// section is created at the end of the file.
+func insertInSection(lines []string, newLine string, section string) []string {
+	header := sectionIndex(lines, section)
+	if header < 0 {
+		newLines := append([]string(nil), lines...)
+		for len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) == "" {
+			newLines = newLines[:len(newLines)-1]
+		}
+		if len(newLines) > 0 {
+			newLines = append(newLines, "")
+		}
+		return append(newLines, "# "+strings.TrimSuffix(strings.TrimSpace(section), ":")+":", insertIndent("")+strings.TrimLeft(newLine, " \t"))
 	}
 
-	if _, err := exec.LookPath("fzf"); err != nil {
-		fmt.Println("Interactive mode requires `fzf` to be installed")
-		fmt.Println("Install it with: sudo pacman -S fzf # or your package manager")
-		os.Exit(1)
+	name, _ := i3config.SectionHeader(lines[header])
+	index, indent := header+1, i3config.BindingIndent(lines[header])
+	for _, binding := range i3config.ParseLines(lines) {
+		if binding.Mode == "" && binding.Section == name {
+			index = binding.EndLine
+			indent = i3config.BindingIndent(lines[binding.Line-1])
+		}
 	}
+	return i3config.InsertLine(lines, index, insertIndent(indent)+strings.TrimLeft(newLine, " \t"))
+}
 
-	lines, err := readConfig()
-	if err != nil {
-		errorColor.Printf("Error: %v\n",err)
-		os.Exit(1)
+// parseIndent parses an --indent value: a number of spaces, or tab.
+func parseIndent(text string) (string, error) {
+	if strings.EqualFold(text, "tab") {
+		return "\t", nil
 	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("invalid indent %s (expected a number of spaces or tab)", text)
+	}
+	return strings.Repeat(" ", n), nil
+}
 
-	bindings := parseBindings(lines)
-	if len(bindings) == 0 {
-		fmt.Println("No keybindings found in config file")
-		return
+// insertIndent returns the indent of an added binding: --indent when given,
+// otherwise detected, the indent of the bindings around it.
+func insertIndent(detected string) string {
+	if addIndent == "" {
+		return detected
 	}
+	indent, _ := parseIndent(addIndent)
+	return indent
+}
 
-	var fzfLines []string
+// checkConflict exits with an error if key is already bound in mode. The
+// same key may be bound differently in other modes.
+func checkConflict(bindings []Binding, key string, mode string) {
 	for _, binding := range bindings {
-
-		displayKey := binding.Key
-		action := binding.Action
-		comment := binding.Comment
-		
-		escapedKey := escapePreview(binding.Key)
-		escapedAction := escapePreview(binding.Action)
-		escapedComment := escapePreview(binding.Comment)
-		
-		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", displayKey, action, comment, escapedKey, escapedAction, escapedComment)
-		
-		fzfLines = append(fzfLines, line)
-	}
-
-	fzfCmd := exec.Command("fzf",
-		"--header=i3-bind: Select a keybindings to manage (Ctrl+C to exit)",
-		"--with-nth=1,2",
-		"--delimiter=\t",
-		"--preview", `echo "Key: {4}"; echo "Action: {5}"; if [ -n "{6}" ]; then echo "Comment: {6}"; fi`,
-		"--preview-window=up:3",
-		"--bind=enter:accept",
-		"--height=40%",
-		)
-
-	fzfCmd.Stdin = strings.NewReader(strings.Join(fzfLines, "\n"))
-	fzfCmd.Stderr = os.Stderr
-
-	output, err := fzfCmd.Output()
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 130 { // Ctrl+C
-					return
-				}
+		if binding.Mode == mode && i3config.KeysMatch(binding.Key, key) {
+			message := fmt.Sprintf("Keybinding %s already exists", key)
+			if mode != "" {
+				message += fmt.Sprintf(" in mode %s", mode)
 			}
+			fatalWithHints(exitExists, message,
+				fmt.Sprintf("Current bindig: %s -> %s", keyColor.Sprint(binding.Key), actionColor.Sprint(binding.Action)),
+				"Use 'i3-bind remove' first or modify the config manually")
 		}
-		fmt.Printf("fzf error: %v\n", err)
-		return
 	}
+}
 
-	selected := strings.TrimSpace(string(output))
-	if selected == "" {
-		return
+// commentStyle returns where the comment command puts comments: inline,
+// above, or auto to keep the binding's current placement. --inline and
+// --above win over $I3_BIND_COMMENT_STYLE, which wins over comment-style in
+// i3-bind's config file.
+func commentStyle() (string, error) {
+	switch {
+	case commentInline:
+		return "inline", nil
+	case commentAbove:
+		return "above", nil
 	}
-
-	columns := strings.Split(selected, "\t")
-	if len(columns) < 1 {
-		fmt.Println("Error parsing selected line")
-		return
+	style := os.Getenv("I3_BIND_COMMENT_STYLE")
+	if style == "" {
+		style = settingsCommentStyle
+	}
+	switch style = strings.ToLower(style); style {
+	case "", "auto":
+		return "auto", nil
+	case "inline", "above":
+		return style, nil
+	default:
+		return "", fmt.Errorf("unknown comment style %s (expected auto, inline or above)", style)
+	}
+}
+
+// stripInlineComment returns line without its inline comment and the spacing
+// before it.
+func stripInlineComment(line string) string {
+	if index := i3config.CommentIndex(line); index > 0 {
+		return strings.TrimRight(line[:index], " \t")
+	}
+	return line
+}
+
+// placeBindingComment sets the comment of binding like i3config.SetBindingComment,
+// but with an inline or above style it also moves the comment to that
+// place, dropping the comment from its other place.
+func placeBindingComment(lines []string, binding Binding, comment string, style string) []string {
+	last := binding.EndLine - 1
+
+	switch style {
+	case "inline":
+		if binding.InlineComment {
+			lines[last] = stripInlineComment(lines[last])
+		}
+		lines[last] = strings.TrimRight(lines[last], " \t") + " # " + comment
+		if start := i3config.BindingStart(binding); start != binding.Line-1 {
+			lines = append(lines[:start], lines[start+1:]...)
+		}
+		return lines
+	case "above":
+		if binding.InlineComment {
+			lines[last] = stripInlineComment(lines[last])
+		}
+		if start := i3config.BindingStart(binding); start != binding.Line-1 {
+			lines[start] = i3config.BindingIndent(lines[binding.Line-1]) + "# " + comment
+			return lines
+		}
+		return i3config.InsertLine(lines, binding.Line-1, i3config.BindingIndent(lines[binding.Line-1])+"# "+comment)
+	}
+	return i3config.SetBindingComment(lines, binding, comment)
+}
+
+func addBinding(cmd *cobra.Command, args []string) {
+	if err := runAdd(args); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+}
+
+// runAdd adds a binding for args[0] with the rest of args as its action,
+// or as the arguments of --template.
+func runAdd(args []string) error {
+	key := args[0]
+	if !addNoNormalize {
+		if canonical := canonicalKey(key); canonical != key {
+			printVerbose("normalized %s to %s", key, canonical)
+			key = canonical
+		}
+	}
+	action := strings.Join(args[1:], " ")
+	if addTemplate != "" {
+		expanded, err := expandTemplate(addTemplate, args[1:])
+		if err != nil {
+			return err
+		}
+		action = expanded
+	}
+
+	files, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	bindings := allBindings(files)
+
+	// With --after/--before the new binding joins the reference binding's
+	// file and mode.
+	mode := addMode
+	targetPath := configPath
+	if addFile != "" {
+		targetPath = addFile
+	}
+	var reference Binding
+	refKey := addAfter
+	if addBefore != "" {
+		refKey = addBefore
+	}
+	if refKey != "" {
+		var ok bool
+		reference, ok = i3config.FindBinding(bindings, refKey)
+		if !ok {
+			return keyNotFoundError(bindings, refKey)
+		}
+		if addMode != "" && reference.Mode != addMode {
+			return fmt.Errorf("Keybinding %s is not in mode %s", refKey, addMode)
+		}
+		mode = reference.Mode
+		targetPath = reference.SourceFile
+	}
+
+	if addSection != "" && addFile == "" {
+		if path, ok := sectionFile(files, addSection); ok {
+			targetPath = path
+		}
+	}
+
+	checkConflict(bindings, key, mode)
+	for _, binding := range bindings {
+		if (binding.Mode == "") != (mode == "") && binding.Type == "bindsym" && i3config.KeysMatch(binding.Key, key) {
+			warningColor.Fprintf(os.Stderr, "Warning: %s is also bound in mode %s (%s)\n", key, modeLabel(binding.Mode), formatLocation(binding))
+		}
+	}
+
+	target, ok := findConfigFile(files, targetPath)
+	if !ok {
+		return fmt.Errorf("%s is not the config or one of its included files", targetPath)
+	}
+	lines := target.Lines
+
+	// A variable copied from another config, such as $mod where this one
+	// sets $alt, makes i3 reject the binding on reload.
+	vars := parseVariables(allLines(files))
+	problems := validateKey(key, false)
+	for _, name := range unresolvedVariables(key, vars) {
+		problems = append(problems, fmt.Sprintf("variable '%s' in %s is never set", name, key))
+	}
+	if len(problems) > 0 {
+		if strictKeys {
+			return fmt.Errorf("%s", strings.Join(problems, "; "))
+		}
+		for _, problem := range problems {
+			warningColor.Fprintf(os.Stderr, "Warning: %s\n", problem)
+		}
+	}
+
+	// The program may be installed later, so a missing one only warns.
+	for _, program := range missingPrograms(action, vars) {
+		warningColor.Fprintf(os.Stderr, "Warning: %s is not in $PATH\n", program)
+	}
+
+	if addIndent != "" {
+		if _, err := parseIndent(addIndent); err != nil {
+			return err
+		}
+	}
+
+	newBinding := fmt.Sprintf("bindsym %s %s", key, action)
+
+	var newLines []string
+	switch {
+	case addAfter != "":
+		newLines = i3config.InsertLine(lines, reference.EndLine, insertIndent(i3config.BindingIndent(lines[reference.Line-1]))+newBinding)
+	case addBefore != "":
+		newLines = i3config.InsertLine(lines, i3config.BindingStart(reference), insertIndent(i3config.BindingIndent(lines[reference.Line-1]))+newBinding)
+	case addSection != "":
+		newLines = insertInSection(lines, newBinding, addSection)
+	default:
+		index, indent, err := i3config.InsertPoint(lines, addMode)
+		if err != nil {
+			return err
+		}
+		newLines = i3config.InsertLine(lines, index, insertIndent(indent)+newBinding)
+	}
+
+	if err := writeConfigFile(target.Path, newLines); err != nil {
+		return err
+	}
+	printSuccess("✓ Added keybinding: %s -> %s\n", keyColor.Sprint(key), actionColor.Sprint(action))
+	reloadIfRequested()
+	return nil
+}
+
+func removeBinding(cmd *cobra.Command, args []string) {
+	if err := runRemove(args, cmd.Flags().Changed("release")); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+}
+
+// runRemove removes the bindings selected by args and the remove flags.
+// filterRelease is set when --release was given, either way.
+func runRemove(args []string, filterRelease bool) error {
+	files, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	if filterRelease && (removeAction != "" || removeMode != "" || targetLine != "") {
+		return fmt.Errorf("--release can only be used with a key")
+	}
+	if removeAction != "" {
+		return removeByAction(files, removeAction)
+	}
+	if removeBlock && removeMode == "" {
+		return fmt.Errorf("--block can only be used with --mode")
+	}
+	if removeMode != "" {
+		return removeModeBindings(files, removeMode)
+	}
+	if targetLine != "" {
+		binding, err := bindingAtLine(files, targetLine)
+		if err != nil {
+			return err
+		}
+		if !confirmRemoval([]Binding{binding}) {
+			printInfo("Nothing removed\n")
+			return nil
+		}
+		return removeMatched(files, []Binding{binding})
+	}
+	var matches []Binding
+	for _, binding := range allBindings(files) {
+		if i3config.KeysMatch(binding.Key, args[0]) && (!filterRelease || hasBindingFlag(binding, "--release") == removeRelease) {
+			matches = append(matches, binding)
+		}
+	}
+	if len(matches) > 0 && !confirmRemoval(matches) {
+		printInfo("Nothing removed\n")
+		return nil
+	}
+	if filterRelease {
+		if len(matches) == 0 {
+			if removeRelease {
+				return notFoundError("Keybinding", "--release "+args[0])
+			}
+			return notFoundError("Keybinding", args[0]+" without --release")
+		}
+		return removeMatched(files, matches)
+	}
+	return removeKeys(files, args)
+}
+
+// hasBindingFlag reports whether binding was declared with flag, such as
+// --release.
+func hasBindingFlag(binding Binding, flag string) bool {
+	for _, f := range binding.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmRemoval asks before removing bindings when stdin and stdout are a
+// terminal, so scripts are never blocked. --yes and --json skip the prompt.
+func confirmRemoval(bindings []Binding) bool {
+	if assumeYes || jsonOutput || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return true
+	}
+	if len(bindings) == 1 {
+		return confirm(fmt.Sprintf("Remove %s -> %s?", formatKey(bindings[0]), actionColor.Sprint(bindings[0].Action)))
+	}
+	for _, binding := range bindings {
+		fmt.Printf("  %s -> %s (%s)\n", formatKey(binding), actionColor.Sprint(binding.Action), formatLocation(binding))
+	}
+	return confirm(fmt.Sprintf("Remove these %d keybindings?", len(bindings)))
+}
+
+// removeByAction removes every binding whose action contains text, matched
+// the same way as find. Without --yes, several matches are only removed
+// after confirming them at a terminal.
+func removeByAction(files []configFile, text string) error {
+	var matches []Binding
+	for _, binding := range allBindings(files) {
+		if containsFold(binding.Action, text) {
+			matches = append(matches, binding)
+		}
+	}
+
+	if len(matches) == 0 {
+		return notFoundError("Keybinding with an action matching", "'"+text+"'")
+	}
+	if len(matches) > 1 && !assumeYes {
+		if jsonOutput || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return fmt.Errorf("%d keybindings have an action matching '%s'; pass --yes to remove them all", len(matches), text)
+		}
+		fmt.Printf("%d keybindings have an action matching '%s':\n", len(matches), text)
+	}
+	if !confirmRemoval(matches) {
+		printInfo("Nothing removed\n")
+		return nil
+	}
+	return removeMatched(files, matches)
+}
+
+// removeModeBindings removes every binding in mode. A mode block left with
+// nothing but blank lines is removed as well, and with --block the whole
+// block goes regardless of what else it holds. Like remove --action it
+// needs --yes when there is no terminal to confirm at.
+func removeModeBindings(files []configFile, mode string) error {
+	var matches []Binding
+	for _, binding := range allBindings(files) {
+		if binding.Mode == mode {
+			matches = append(matches, binding)
+		}
+	}
+	blocks := make(map[string][][2]int)
+	for _, file := range files {
+		if fileBlocks := modeBlocks(file.Lines, mode); len(fileBlocks) > 0 {
+			blocks[file.Path] = fileBlocks
+		}
+	}
+	if len(blocks) == 0 {
+		return notFoundError("Mode", mode)
+	}
+	if len(matches) == 0 && !removeBlock {
+		return fmt.Errorf("mode %s has no keybindings; pass --block to remove the mode block", mode)
+	}
+
+	if !assumeYes {
+		if jsonOutput || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return fmt.Errorf("this would remove %d keybinding(s) from mode %s; pass --yes to confirm", len(matches), mode)
+		}
+		for _, binding := range matches {
+			fmt.Printf("  %s -> %s (%s)\n", formatKey(binding), actionColor.Sprint(binding.Action), formatLocation(binding))
+		}
+		prompt := fmt.Sprintf("Remove these %d keybinding(s) from mode %s?", len(matches), mode)
+		if removeBlock {
+			prompt = fmt.Sprintf("Remove mode %s and its %d keybinding(s)?", mode, len(matches))
+		}
+		if !confirm(prompt) {
+			printInfo("Nothing removed\n")
+			return nil
+		}
+	}
+
+	removeLines := make(map[string]map[int]bool)
+	for _, binding := range matches {
+		if err := markBindingLines(removeLines, files, binding); err != nil {
+			return err
+		}
+	}
+
+	blockRemoved := false
+	for _, file := range files {
+		for _, block := range blocks[file.Path] {
+			if !removeBlock && !onlyBlankLeft(file.Lines, block, removeLines[file.Path]) {
+				continue
+			}
+			if removeLines[file.Path] == nil {
+				removeLines[file.Path] = make(map[int]bool)
+			}
+			for index := block[0]; index <= block[1] && index < len(file.Lines); index++ {
+				removeLines[file.Path][index] = true
+			}
+			blockRemoved = true
+		}
+	}
+
+	if err := removeLineSet(files, removeLines, matches); err != nil {
+		return err
+	}
+	if blockRemoved {
+		printSuccess("✓ Removed mode block %s\n", modeColor.Sprint(mode))
+		for _, binding := range allBindings(files) {
+			if binding.Mode != mode && strings.Trim(binding.ModeSwitch, `"`) == mode {
+				warningColor.Fprintf(os.Stderr, "Warning: %s still switches to mode %s\n", formatKey(binding), mode)
+			}
+		}
+	}
+	return nil
+}
+
+// modeBlocks returns the 0-based header and closing brace indexes of every
+// block of the named mode in lines.
+func modeBlocks(lines []string, name string) [][2]int {
+	var blocks [][2]int
+	for offset := 0; offset < len(lines); {
+		start, end, ok := i3config.FindModeBlock(lines[offset:], name)
+		if !ok {
+			break
+		}
+		blocks = append(blocks, [2]int{offset + start, offset + end})
+		offset += end + 1
+	}
+	return blocks
+}
+
+// onlyBlankLeft reports whether every line inside block is blank or about
+// to be removed.
+func onlyBlankLeft(lines []string, block [2]int, remove map[int]bool) bool {
+	for index := block[0] + 1; index < block[1] && index < len(lines); index++ {
+		if !remove[index] && strings.TrimSpace(lines[index]) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// removeKeys removes every binding of each key from the loaded files,
+// writing each affected file once.
+func removeKeys(files []configFile, keys []string) error {
+	bindings := allBindings(files)
+	var removedBindings []Binding
+
+	// removeLines maps each affected file to the 0-based indexes to drop.
+	removeLines := make(map[string]map[int]bool)
+
+	for _, key := range keys {
+		found := false
+		for _, binding := range bindings {
+			if i3config.KeysMatch(binding.Key, key) {
+				found = true
+				if removeLines[binding.SourceFile][binding.Line-1] {
+					// Already removed for an earlier spelling of the key.
+					continue
+				}
+				removedBindings = append(removedBindings, binding)
+				if err := markBindingLines(removeLines, files, binding); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !found {
+			return keyNotFoundError(bindings, key)
+		}
+	}
+
+	return removeLineSet(files, removeLines, removedBindings)
+}
+
+// removeMatched removes exactly the given bindings from the loaded files.
+func removeMatched(files []configFile, matched []Binding) error {
+	removeLines := make(map[string]map[int]bool)
+	for _, binding := range matched {
+		if err := markBindingLines(removeLines, files, binding); err != nil {
+			return err
+		}
+	}
+	return removeLineSet(files, removeLines, matched)
+}
+
+// markBindingLines adds the lines of binding to removeLines, including its
+// comment line above unless --keep-comment is set. It first checks that the
+// binding's first line still carries exactly its key as the key token, so a
+// key that is a prefix of another ($mod+1 and $mod+10) or text elsewhere on
+// the line can never cause the wrong line to be dropped.
+func markBindingLines(removeLines map[string]map[int]bool, files []configFile, binding Binding) error {
+	file, ok := findConfigFile(files, binding.SourceFile)
+	if !ok || binding.Line < 1 || binding.EndLine > len(file.Lines) {
+		return fmt.Errorf("keybinding %s is no longer at %s", binding.Key, formatLocation(binding))
+	}
+	if key, ok := i3config.KeyToken(file.Lines[binding.Line-1]); !ok || key != binding.Key {
+		return fmt.Errorf("line %d of %s no longer binds %s, refusing to remove it", binding.Line, file.Path, binding.Key)
+	}
+
+	if removeLines[binding.SourceFile] == nil {
+		removeLines[binding.SourceFile] = make(map[int]bool)
+	}
+	start := binding.Line - 1
+	if !keepComment {
+		start = i3config.BindingStart(binding)
+	}
+	for index := start; index < binding.EndLine; index++ {
+		removeLines[binding.SourceFile][index] = true
+	}
+	return nil
+}
+
+// removeLineSet drops the 0-based line indexes in removeLines from each
+// file, writing each affected file once, and reports the removed bindings.
+func removeLineSet(files []configFile, removeLines map[string]map[int]bool, removedBindings []Binding) error {
+	for _, file := range files {
+		remove := removeLines[file.Path]
+		if len(remove) == 0 {
+			continue
+		}
+
+		newLines := make([]string, 0, len(file.Lines)-len(remove))
+		for i, line := range file.Lines {
+			if !remove[i] {
+				newLines = append(newLines, line)
+			}
+		}
+
+		if err := writeConfigFile(file.Path, newLines); err != nil {
+			return err
+		}
+	}
+
+	for _, removedBinding := range removedBindings {
+		printSuccess("✓ Removed keybinding: %s -> %s\n", formatKey(removedBinding), actionColor.Sprint(removedBinding.Action))
+	}
+	reloadIfRequested()
+	return nil
+}
+
+func editBinding(cmd *cobra.Command, args []string) {
+	if err := runEdit(args); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+}
+
+// runEdit sets the action of the binding for args[0], or at --line, to the
+// rest of args.
+func runEdit(args []string) error {
+	files, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	if targetLine != "" {
+		binding, err := bindingAtLine(files, targetLine)
+		if err != nil {
+			return err
+		}
+		return setAction(files, binding, strings.Join(args, " "))
+	}
+	return editKey(files, args[0], strings.Join(args[1:], " "))
+}
+
+// bindingAtLine returns the binding defined at a line given as N for the
+// main config or FILE:N for an included file. Any line of a binding
+// continued over several lines selects it.
+func bindingAtLine(files []configFile, spec string) (Binding, error) {
+	path, lineText := configPath, spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		path, lineText = spec[:i], spec[i+1:]
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		return Binding{}, fmt.Errorf("invalid line %s (expected N or FILE:N)", spec)
+	}
+	file, ok := findConfigFile(files, path)
+	if !ok {
+		return Binding{}, fmt.Errorf("%s is not the config or one of its included files", path)
+	}
+
+	for _, binding := range allBindings(files) {
+		if binding.SourceFile == file.Path && binding.Line <= line && line <= binding.EndLine {
+			return binding, nil
+		}
+	}
+	return Binding{}, notFoundError("Keybinding at line", spec)
+}
+
+// editKey replaces the action of the binding for key in the loaded files.
+func editKey(files []configFile, key, action string) error {
+	bindings := allBindings(files)
+	binding, ok := i3config.FindBinding(bindings, key)
+	if !ok {
+		return keyNotFoundError(bindings, key)
+	}
+	return setAction(files, binding, action)
+}
+
+// setAction replaces the action of binding in its file.
+func setAction(files []configFile, binding Binding, action string) error {
+	file, _ := findConfigFile(files, binding.SourceFile)
+	if err := writeConfigFile(file.Path, i3config.SetBindingAction(file.Lines, binding, action)); err != nil {
+		return err
+	}
+
+	printSuccess("✓ Updated keybinding: %s -> %s (was %s)\n", formatKey(binding), actionColor.Sprint(action), binding.Action)
+	reloadIfRequested()
+	return nil
+}
+
+func renameBinding(cmd *cobra.Command, args []string) {
+	oldKey := args[0]
+	newKey := args[1]
+
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	bindings := allBindings(files)
+	found := false
+	var renamedBinding Binding
+
+	for _, binding := range bindings {
+		if i3config.KeysMatch(binding.Key, oldKey) {
+			found = true
+			renamedBinding = binding
+			break
+		}
+	}
+
+	if !found {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, oldKey))
+	}
+
+	if !i3config.KeysMatch(oldKey, newKey) {
+		checkConflict(bindings, newKey, renamedBinding.Mode)
+	}
+
+	file, _ := findConfigFile(files, renamedBinding.SourceFile)
+	lines := file.Lines
+	keyRegex := regexp.MustCompile(`^(\s*(?:bindsym|bindcode|bindgesture)(?:\s+--[^\s]+)*\s+)([^\s]+)(.*)$`)
+	index := renamedBinding.Line - 1
+	lines[index] = keyRegex.ReplaceAllString(lines[index], "${1}"+strings.ReplaceAll(newKey, "$", "$$")+"${3}")
+
+	if err := writeConfigFile(file.Path, lines); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	printSuccess("✓ Renamed keybinding: %s -> %s\n", keyColor.Sprint(renamedBinding.Key), keyColor.Sprint(newKey))
+	reloadIfRequested()
+}
+
+// runToggle disables or enables the binding for key, exiting on error.
+func runToggle(key string, enable bool) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	if err := toggleBinding(files, key, enable); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+}
+
+// toggleBinding disables an enabled binding by commenting it out with a
+// [disabled] marker, or re-enables a binding carrying that marker.
+func toggleBinding(files []configFile, key string, enable bool) error {
+	found := false
+	var target Binding
+	var candidates []Binding
+	for _, binding := range allBindingsWithDisabled(files, true) {
+		if binding.Disabled != enable {
+			continue
+		}
+		candidates = append(candidates, binding)
+		if !found && i3config.KeysMatch(binding.Key, key) {
+			found = true
+			target = binding
+		}
+	}
+
+	if !found {
+		if enable {
+			return notFoundError("Disabled keybinding", key)
+		}
+		return keyNotFoundError(candidates, key)
+	}
+
+	file, _ := findConfigFile(files, target.SourceFile)
+	if err := writeConfigFile(file.Path, i3config.SetBindingDisabled(file.Lines, target, !enable)); err != nil {
+		return err
+	}
+
+	if enable {
+		printSuccess("✓ Enabled keybinding: %s -> %s\n", formatKey(target), actionColor.Sprint(target.Action))
+	} else {
+		printSuccess("✓ Disabled keybinding: %s -> %s\n", formatKey(target), actionColor.Sprint(target.Action))
+	}
+	reloadIfRequested()
+	return nil
+}
+
+func swapBindings(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	bindings := allBindings(files)
+	first, ok := i3config.FindBinding(bindings, args[0])
+	if !ok {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, args[0]))
+	}
+	second, ok := i3config.FindBinding(bindings, args[1])
+	if !ok {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, args[1]))
+	}
+	if first.SourceFile == second.SourceFile && first.Line == second.Line {
+		fatalf(exitError, "%s and %s are the same keybinding", args[0], args[1])
+	}
+
+	// Rewrite the later binding first so collapsing a multi-line binding
+	// doesn't shift the line of the other one.
+	if first.SourceFile == second.SourceFile {
+		file, _ := findConfigFile(files, first.SourceFile)
+		lines := file.Lines
+		later, earlier := first, second
+		laterAction, earlierAction := second.Action, first.Action
+		if first.Line < second.Line {
+			later, earlier = second, first
+			laterAction, earlierAction = first.Action, second.Action
+		}
+		lines = i3config.SetBindingAction(lines, later, laterAction)
+		lines = i3config.SetBindingAction(lines, earlier, earlierAction)
+		if err := writeConfigFile(file.Path, lines); err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+	} else {
+		for _, pair := range [][2]Binding{{first, second}, {second, first}} {
+			file, _ := findConfigFile(files, pair[0].SourceFile)
+			if err := writeConfigFile(file.Path, i3config.SetBindingAction(file.Lines, pair[0], pair[1].Action)); err != nil {
+				fatalf(exitCode(err), "%v", err)
+			}
+		}
+	}
+
+	printSuccess("✓ Swapped keybindings: %s -> %s, %s -> %s\n",
+		keyColor.Sprint(first.Key), actionColor.Sprint(second.Action),
+		keyColor.Sprint(second.Key), actionColor.Sprint(first.Action))
+	reloadIfRequested()
+}
+
+func moveBinding(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	refKey := moveAfter
+	if moveBefore != "" {
+		refKey = moveBefore
+	}
+
+	bindings := allBindings(files)
+	binding, ok := i3config.FindBinding(bindings, args[0])
+	if !ok {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, args[0]))
+	}
+	reference, ok := i3config.FindBinding(bindings, refKey)
+	if !ok {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, refKey))
+	}
+	if binding.SourceFile == reference.SourceFile && binding.Line == reference.Line {
+		fatalf(exitError, "%s and %s are the same keybinding", args[0], refKey)
+	}
+	if binding.SourceFile != reference.SourceFile {
+		fatalf(exitError, "%s and %s are in different files", args[0], refKey)
+	}
+	if binding.Mode != reference.Mode {
+		fatalf(exitError, "%s and %s are in different modes", args[0], refKey)
+	}
+
+	file, _ := findConfigFile(files, binding.SourceFile)
+	lines := file.Lines
+
+	// Cut the binding with its detached comment, then find the insertion
+	// point in the remaining lines.
+	start := i3config.BindingStart(binding)
+	block := append([]string(nil), lines[start:binding.EndLine]...)
+	rest := append(append([]string(nil), lines[:start]...), lines[binding.EndLine:]...)
+
+	index := reference.EndLine
+	if moveBefore != "" {
+		index = i3config.BindingStart(reference)
+	}
+	if index > start {
+		index -= len(block)
+	}
+
+	newLines := append(append(append([]string(nil), rest[:index]...), block...), rest[index:]...)
+	if err := writeConfigFile(file.Path, newLines); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	position := "after"
+	if moveBefore != "" {
+		position = "before"
+	}
+	printSuccess("✓ Moved keybinding %s %s %s\n", keyColor.Sprint(binding.Key), position, keyColor.Sprint(reference.Key))
+	reloadIfRequested()
+}
+
+func listBindings(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	lines := allLines(files)
+	bindings := allBindingsWithDisabled(files, listAll)
+	vars := parseVariables(lines)
+
+	if listUnbound {
+		printUnbound(bindings, vars)
+		return
+	}
+
+	if expandVars {
+		bindings = expandBindings(bindings, vars)
+	}
+	bindings = listFilter.apply(bindings)
+	// Sections are listed in config order, so take it before sorting.
+	sections := sectionNames(bindings)
+	if err := sortBindingsBy(bindings, listSort, listReverse); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	if listLimit < 0 {
+		fatalf(exitError, "--limit can't be negative")
+	}
+	total := len(bindings)
+	bindings, hidden := limitBindings(bindings, listLimit)
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Found %d keybindings", total), nonNil(bindings))
+		return
+	}
+
+	if listGroupBySection && listFormat != "text" {
+		fatalf(exitError, "--group-by-section only works with the text format")
+	}
+	switch listFormat {
+	case "text":
+	case "json":
+		printJSON(bindings)
+		return
+	case "tsv":
+		printTSV(bindings)
+		return
+	case "table":
+		withPager(func() {
+			printTable(bindings)
+			printHidden(hidden)
+		})
+		return
+	default:
+		fatalf(exitError, "Unknown format %s (expected text, table, json or tsv)", listFormat)
+	}
+
+	if len(bindings) == 0 {
+		fmt.Println("No keybindings found in config file")
+		return
+	}
+
+	withPager(func() {
+		if listGroupBySection {
+			printBySection(bindings, sections, vars)
+		} else {
+			printBindingList(bindings, vars)
+		}
+		printHidden(hidden)
+	})
+}
+
+// limitBindings returns the first limit bindings, or all of them when
+// limit is 0, along with the number left out.
+func limitBindings(bindings []Binding, limit int) ([]Binding, int) {
+	if limit == 0 || len(bindings) <= limit {
+		return bindings, 0
+	}
+	return bindings[:limit], len(bindings) - limit
+}
+
+// printHidden notes how many bindings --limit left out.
+func printHidden(hidden int) {
+	if hidden > 0 {
+		printInfo("\n%d more not shown (--limit)\n", hidden)
+	}
+}
+
+// sectionNames returns the distinct sections of bindings in the order they
+// first appear.
+func sectionNames(bindings []Binding) []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, binding := range bindings {
+		if !seen[binding.Section] {
+			seen[binding.Section] = true
+			sections = append(sections, binding.Section)
+		}
+	}
+	return sections
+}
+
+// printBySection prints the bindings grouped under their section headers,
+// in the order of sections, marking those inside a mode.
+func printBySection(bindings []Binding, sections []string, vars map[string]string) {
+	fmt.Printf("Found %d keybindings in %s:\n", len(bindings), configPath)
+
+	bySection := make(map[string][]Binding)
+	for _, binding := range bindings {
+		bySection[binding.Section] = append(bySection[binding.Section], binding)
+	}
+	for _, section := range sections {
+		name := section
+		if name == "" {
+			name = "(no section)"
+		}
+		fmt.Printf("\n%s\n", modeColor.Sprintf("%s:", name))
+		for _, binding := range bySection[section] {
+			printListedBinding(binding, vars, true)
+		}
+	}
+}
+
+// printBindingList prints the bindings grouped by mode in list's text
+// format, resolving mode switch targets through vars.
+func printBindingList(bindings []Binding, vars map[string]string) {
+	fmt.Printf("Found %d keybindings in %s:\n", len(bindings), configPath)
+
+	modes, byMode := groupByMode(bindings)
+	for _, mode := range modes {
+		fmt.Printf("\n%s\n", modeColor.Sprintf("Mode: %s", modeLabel(mode)))
+		for _, binding := range byMode[mode] {
+			printListedBinding(binding, vars, false)
+		}
+	}
+}
+
+// printListedBinding prints one line of list's text format. showMode adds
+// the mode of bindings inside one, for views not grouped by mode.
+func printListedBinding(binding Binding, vars map[string]string, showMode bool) {
+	modeTag := ""
+	if showMode && binding.Mode != "" {
+		modeTag = " " + modeColor.Sprintf("[mode: %s]", binding.Mode)
+	}
+	if binding.Disabled {
+		key := strings.Join(append(append([]string(nil), binding.Flags...), binding.Key), " ")
+		fmt.Printf("  %s", disabledColor.Sprintf("%s -> %s", key, binding.Action))
+		if binding.Comment != "" {
+			fmt.Printf(" %s", disabledColor.Sprintf("# %s", binding.Comment))
+		}
+		fmt.Printf("%s %s\n", modeTag, disabledColor.Sprint("[disabled]"))
+		return
+	}
+	fmt.Printf("  %s -> %s", formatKey(binding),actionColor.Sprint(binding.Action))
+	if binding.ModeSwitch != "" {
+		target := strings.Trim(expandVariables(binding.ModeSwitch, vars), `"`)
+		if target == "default" {
+			fmt.Printf(" %s", modeColor.Sprint("⇐ default"))
+		} else {
+			fmt.Printf(" %s", modeColor.Sprintf("⇒ %s", target))
+		}
+	}
+	if binding.Comment != "" {
+		fmt.Printf(" %s", commentColor.Sprintf("# %s",binding.Comment))
+	}
+	fmt.Println(modeTag)
+}
+
+// keyModifiers returns the normalized modifiers of a key, without the final
+// keysym.
+func keyModifiers(key string) []string {
+	tokens := strings.Split(i3config.NormalizeKey(key), "+")
+	return tokens[:len(tokens)-1]
+}
+
+func (f bindingFilter) apply(bindings []Binding) []Binding {
+	var wanted []string
+	if f.Modifier != "" {
+		for _, token := range strings.Split(strings.Trim(f.Modifier, "+"), "+") {
+			wanted = append(wanted, i3config.NormalizeModifier(token))
+		}
+	}
+	mode := f.Mode
+	if mode == "default" {
+		mode = ""
+	}
+
+	var filtered []Binding
+	for _, binding := range bindings {
+		if f.Mode != "" && binding.Mode != mode {
+			continue
+		}
+		if f.ActionPrefix != "" && !strings.HasPrefix(strings.ToLower(binding.Action), strings.ToLower(f.ActionPrefix)) {
+			continue
+		}
+		if len(wanted) > 0 {
+			have := make(map[string]bool)
+			for _, modifier := range keyModifiers(binding.Key) {
+				have[modifier] = true
+			}
+			matched := true
+			for _, modifier := range wanted {
+				if !have[modifier] {
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		filtered = append(filtered, binding)
+	}
+	return filtered
+}
+
+// groupByMode splits bindings by mode, returning the mode names with the
+// global scope first and the rest in order of first appearance.
+func groupByMode(bindings []Binding) ([]string, map[string][]Binding) {
+	var modes []string
+	byMode := make(map[string][]Binding)
+	for _, binding := range bindings {
+		if _, ok := byMode[binding.Mode]; !ok {
+			modes = append(modes, binding.Mode)
+		}
+		byMode[binding.Mode] = append(byMode[binding.Mode], binding)
+	}
+	sort.SliceStable(modes, func(i, j int) bool {
+		return modes[i] == "" && modes[j] != ""
+	})
+	return modes, byMode
+}
+
+// nonNil returns bindings, or an empty slice so JSON output shows [] rather
+// than null.
+func nonNil(bindings []Binding) []Binding {
+	if bindings == nil {
+		return []Binding{}
+	}
+	return bindings
+}
+
+// printJSON writes bindings to stdout as an indented JSON array, using an
+// empty array rather than null when there are none.
+func printJSON(bindings []Binding) {
+	data, err := json.MarshalIndent(nonNil(bindings), "", "  ")
+	if err != nil {
+		fatalf(exitError, "failed to encode JSON: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// tsvField replaces tabs so a value can't break the column layout.
+func tsvField(text string) string {
+	return strings.ReplaceAll(text, "\t", " ")
+}
+
+// printTSV writes one key, action, comment and line number per row, without
+// color or a header, for awk and cut.
+func printTSV(bindings []Binding) {
+	for _, binding := range bindings {
+		fmt.Printf("%s\t%s\t%s\t%d\n", tsvField(binding.Key), tsvField(binding.Action), tsvField(binding.Comment), binding.Line)
+	}
+}
+
+// plainKey is formatKey without color, for measuring column widths.
+func plainKey(binding Binding) string {
+	key := strings.Join(append(append([]string(nil), binding.Flags...), binding.Key), " ")
+	if binding.IsCode {
+		key += " [code]"
+	}
+	if binding.Type == "bindgesture" {
+		key += " [gesture]"
+	}
+	return key
+}
+
+// pad returns the padding that widens text to width columns.
+func pad(text string, width int) string {
+	return strings.Repeat(" ", width-utf8.RuneCountInString(text))
+}
+
+// printTable prints the bindings grouped by mode in aligned key, action
+// and comment columns. Widths are shared by all modes so every group lines
+// up the same way.
+func printTable(bindings []Binding) {
+	keyWidth, actionWidth := len("KEY"), len("ACTION")
+	for _, binding := range bindings {
+		if width := utf8.RuneCountInString(plainKey(binding)); width > keyWidth {
+			keyWidth = width
+		}
+		if width := utf8.RuneCountInString(binding.Action); width > actionWidth {
+			actionWidth = width
+		}
+	}
+
+	modes, byMode := groupByMode(bindings)
+	for i, mode := range modes {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(modeColor.Sprintf("Mode: %s", modeLabel(mode)))
+		fmt.Printf("  %s%s  %s%s  %s\n", "KEY", pad("KEY", keyWidth), "ACTION", pad("ACTION", actionWidth), "COMMENT")
+		fmt.Printf("  %s  %s  %s\n", strings.Repeat("─", keyWidth), strings.Repeat("─", actionWidth), strings.Repeat("─", len("COMMENT")))
+		for _, binding := range byMode[mode] {
+			key, action, comment := plainKey(binding), binding.Action, binding.Comment
+			if binding.Disabled {
+				fmt.Println(disabledColor.Sprintf("  %s%s  %s%s  %s [disabled]", key, pad(key, keyWidth), action, pad(action, actionWidth), comment))
+				continue
+			}
+			if comment == "" {
+				fmt.Printf("  %s%s  %s\n", formatKey(binding), pad(key, keyWidth), actionColor.Sprint(action))
+				continue
+			}
+			fmt.Printf("  %s%s  %s%s  %s\n", formatKey(binding), pad(key, keyWidth), actionColor.Sprint(action), pad(action, actionWidth), commentColor.Sprint(comment))
+		}
+	}
+}
+
+// markdownCell escapes text for use inside a Markdown table cell.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+func exportBindings(cmd *cobra.Command, args []string) {
+	_, bindings, err := loadBindings()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	sortBindings(bindings)
+
+	if jsonOutput {
+		printResult("ok", "", nonNil(bindings))
+		return
 	}
-	// parts := strings.SplitN(selected, " -> ", 2)
-	// if len(parts) < 2 {
-	// 	fmt.Println("Error parsing selected line")
-	// 	return
-	// }
-
-	selectedKey := columns[0]
-
-	fmt.Printf("\nSelected keybinding: %s\n", keyColor.Sprint(selectedKey))
-	fmt.Println("\nWhat would you like to do?")
-	fmt.Println("1. Remove this keybinding")
-	fmt.Println("2. Add/Update comment")
-	fmt.Println("3. Show details")
-	fmt.Println("4. Cancel")
 
-	fmt.Print("\nEnter your choice (1-4): ")
-	reader := bufio.NewReader(os.Stdin)
-	choice, _ := reader.ReadString('\n')
-	choice = strings.TrimSpace(choice)
-
-	switch choice {
-	case "1":
-		removeBinding(cmd, []string{selectedKey})
-	case "2":
-		fmt.Print("Enter comment: ")
-		comment, _ := reader.ReadString('\n')
-		comment = strings.TrimSpace(comment)
-		if comment != "" {
-			commentBinding(cmd, []string{selectedKey, comment})
-		}
-	case "3":
-		for _, binding := range bindings {
-			if binding.Key == selectedKey {
-				fmt.Printf("\nKeybinding Details:\n")
-				fmt.Printf("  Key: %s\n", keyColor.Sprint(binding.Key))
-				fmt.Printf("  Action: %s\n", actionColor.Sprint(binding.Action))
-				if binding.Comment != "" {
-					fmt.Printf("  Comment: %s\n", commentColor.Sprint(binding.Comment))
+	switch exportFormat {
+	case "json":
+		printJSON(bindings)
+	case "markdown", "md":
+		fmt.Println("# i3 keybindings")
+		modes, byMode := groupByMode(bindings)
+		for _, mode := range modes {
+			fmt.Printf("\n## %s\n\n", modeLabel(mode))
+			fmt.Println("| Key | Action | Comment |")
+			fmt.Println("| --- | --- | --- |")
+			for _, binding := range byMode[mode] {
+				key := binding.Key
+				if binding.IsCode {
+					key += " (keycode)"
 				}
-				fmt.Printf("  Line: %d\n", binding.Line)
-				fmt.Printf("  Raw: %s\n", binding.Raw)
-				break
+				if binding.Type == "bindgesture" {
+					key += " (gesture)"
+				}
+				fmt.Printf("| `%s` | %s | %s |\n", markdownCell(key), markdownCell(binding.Action), markdownCell(binding.Comment))
 			}
 		}
-	case "4":
-		fmt.Println("Cancelled")
 	default:
-		fmt.Println("Invalid choice")
+		fatalf(exitError, "Unknown format %s (expected markdown or json)", exportFormat)
+	}
+}
+
+// containsFold reports whether s contains term, ignoring case. It is the
+// matching used by find and remove --action.
+func containsFold(s, term string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(term))
+}
+
+// matchesSearch reports whether term occurs in the fields of binding chosen
+// with --key-only, --action-only and --comment-only, or in any of them when
+// none is set.
+func matchesSearch(binding Binding, term string) bool {
+	all := !findKeyOnly && !findActionOnly && !findCommentOnly
+	return ((all || findKeyOnly) && containsFold(binding.Key, term)) ||
+		((all || findActionOnly) && containsFold(binding.Action, term)) ||
+		((all || findCommentOnly) && containsFold(binding.Comment, term))
+}
+
+func findBindings(cmd *cobra.Command, args []string) {
+	searchTerm := args[0]
+
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	lines, bindings := allLines(files), allBindings(files)
+
+	if expandVars {
+		bindings = expandBindings(bindings, parseVariables(lines))
+	}
+	if findAfter < 0 || findBefore < 0 || findContext < 0 {
+		fatalf(exitError, "context line counts can't be negative")
+	}
+	if !cmd.Flags().Changed("after-context") {
+		findAfter = findContext
+	}
+	if !cmd.Flags().Changed("before-context") {
+		findBefore = findContext
+	}
+	var matches []Binding
+
+	for _, binding := range bindings {
+		if matchesSearch(binding, searchTerm) {
+			matches = append(matches, binding)
+		}
+	}
+
+	if findCount {
+		if jsonOutput {
+			printResult("ok", fmt.Sprintf("Found %d keybinding(s) matching '%s'", len(matches), searchTerm), len(matches))
+			return
+		}
+		fmt.Println(len(matches))
+		return
+	}
+
+	if findFiles {
+		files := []string{}
+		seen := make(map[string]bool)
+		for _, binding := range matches {
+			if !seen[binding.SourceFile] {
+				seen[binding.SourceFile] = true
+				files = append(files, binding.SourceFile)
+			}
+		}
+		if jsonOutput {
+			printResult("ok", fmt.Sprintf("Found keybindings matching '%s' in %d file(s)", searchTerm, len(files)), files)
+			return
+		}
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		return
+	}
+
+	if findLimit < 0 {
+		fatalf(exitError, "--limit can't be negative")
+	}
+	total := len(matches)
+	matches, hidden := limitBindings(matches, findLimit)
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Found %d keybinding(s) matching '%s'", total, searchTerm), nonNil(matches))
+		return
+	}
+
+	if len(matches) == 0 {
+		fmt.Printf("No keybindings found matching '%s'\n", searchTerm)
+		return
+	}
+
+	fmt.Printf("Found %d keybinding(s) matching '%s':\n\n", total, searchTerm)
+
+	for _, binding := range matches {
+		fmt.Printf("  %s -> %s", formatKey(binding), actionColor.Sprint(binding.Action))
+		if binding.Comment != "" {
+			fmt.Printf(" %s", commentColor.Sprintf("# %s", binding.Comment))
+		}
+		if binding.Mode != "" {
+			fmt.Printf(" %s", modeColor.Sprintf("[mode: %s]", binding.Mode))
+		}
+		fmt.Printf(" %s\n", color.New(color.FgBlack, color.Bold).Sprintf("(%s)", formatLocation(binding)))
+		if findBefore > 0 || findAfter > 0 {
+			if file, ok := findConfigFile(files, binding.SourceFile); ok {
+				printContext(file.Lines, binding, findBefore, findAfter)
+			}
+		}
+	}
+	printHidden(hidden)
+}
+
+// printContext prints the raw config lines of binding with before lines
+// above and after lines below it, numbered like grep: ":" marks the
+// binding's own lines and "-" the context.
+func printContext(lines []string, binding Binding, before, after int) {
+	start := max(binding.Line-before, 1)
+	end := min(binding.EndLine+after, len(lines))
+	width := len(strconv.Itoa(end))
+	for line := start; line <= end; line++ {
+		separator := "-"
+		if line >= binding.Line && line <= binding.EndLine {
+			separator = ":"
+		}
+		number := color.New(color.FgBlack, color.Bold).Sprintf("%*d%s", width, line, separator)
+		fmt.Printf("      %s %s\n", number, lines[line-1])
+	}
+	fmt.Println()
+}
+
+func commentBinding(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	comment := ""
+	if !commentClear {
+		comment = args[len(args)-1]
+	}
+	if commentAction != "" {
+		if err := commentByAction(files, commentAction, comment); err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		return
+	}
+	if targetLine != "" {
+		binding, err := bindingAtLine(files, targetLine)
+		if err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		if err := setComment(files, binding, comment); err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		return
+	}
+	if err := commentKey(files, args[0], comment); err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+}
+
+// updateComment sets the comment of binding in lines, or removes it with
+// --clear.
+func updateComment(lines []string, binding Binding, comment string, style string) []string {
+	if commentClear {
+		return i3config.ClearBindingComment(lines, binding)
+	}
+	return placeBindingComment(lines, binding, comment, style)
+}
+
+// printCommented reports a comment set or cleared by updateComment.
+func printCommented(binding Binding, comment string) {
+	if commentClear {
+		printSuccess("✓ Removed comment from keybinding: %s (was # %s)\n", formatKey(binding), commentColor.Sprint(binding.Comment))
+		return
+	}
+	printSuccess("✓ Added comment to keybinding: %s # %s\n", formatKey(binding), commentColor.Sprint(comment))
+}
+
+// commentByAction sets the comment of the bindings whose action contains
+// text, matched the same way as find. When several match, they are only all
+// commented with --all or after confirming at a terminal. With --clear,
+// bindings without a comment are left out.
+func commentByAction(files []configFile, text, comment string) error {
+	var matches []Binding
+	for _, binding := range allBindings(files) {
+		if containsFold(binding.Action, text) && (!commentClear || binding.Comment != "") {
+			matches = append(matches, binding)
+		}
+	}
+
+	if len(matches) == 0 {
+		if commentClear {
+			return notFoundError("Commented keybinding with an action matching", "'"+text+"'")
+		}
+		return notFoundError("Keybinding with an action matching", "'"+text+"'")
+	}
+	verb := "comment"
+	if commentClear {
+		verb = "clear the comments of"
+	}
+	if len(matches) > 1 && !commentAll {
+		if jsonOutput || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return fmt.Errorf("%d keybindings have an action matching '%s'; pass --all to %s them all", len(matches), text, verb)
+		}
+		fmt.Printf("%d keybindings have an action matching '%s':\n", len(matches), text)
+		for _, binding := range matches {
+			fmt.Printf("  %s -> %s (%s)\n", formatKey(binding), actionColor.Sprint(binding.Action), formatLocation(binding))
+		}
+		if !confirm(fmt.Sprintf("%s all %d of them?", strings.ToUpper(verb[:1])+verb[1:], len(matches))) {
+			printInfo("Nothing changed\n")
+			return nil
+		}
+	}
+
+	style, err := commentStyle()
+	if err != nil {
+		return err
+	}
+
+	// Comment from the bottom of each file up, since adding or removing a
+	// comment line shifts the lines below it.
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Line > matches[j].Line
+	})
+	for _, file := range files {
+		lines := file.Lines
+		changed := false
+		for _, binding := range matches {
+			if binding.SourceFile == file.Path {
+				lines = updateComment(lines, binding, comment, style)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := writeConfigFile(file.Path, lines); err != nil {
+			return err
+		}
+	}
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		printCommented(matches[i], comment)
+	}
+	reloadIfRequested()
+	return nil
+}
+
+// commentKey sets the comment of the binding for key in the loaded files.
+func commentKey(files []configFile, key, comment string) error {
+	bindings := allBindings(files)
+	found := false
+	var commentedBinding Binding
+
+	for _, binding := range bindings {
+		if i3config.KeysMatch(binding.Key, key) {
+			found = true
+			commentedBinding = binding
+			break
+		}
+	}
+
+	if !found {
+		return keyNotFoundError(bindings, key)
+	}
+	return setComment(files, commentedBinding, comment)
+}
+
+// setComment sets the comment of binding in its file, or removes it with
+// --clear.
+func setComment(files []configFile, binding Binding, comment string) error {
+	if commentClear && binding.Comment == "" {
+		printInfo("Keybinding %s has no comment\n", formatKey(binding))
+		return nil
+	}
+	file, _ := findConfigFile(files, binding.SourceFile)
+	style, err := commentStyle()
+	if err != nil {
+		return err
 	}
+	lines := updateComment(file.Lines, binding, comment, style)
 
+	if err := writeConfigFile(file.Path, lines); err != nil {
+		return err
+	}
+	printCommented(binding, comment)
+	reloadIfRequested()
+	return nil
+}
+
+func validateBindings(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	lines, bindings := allLines(files), allBindings(files)
+
+	type keyProblem struct {
+		Binding Binding `json:"binding"`
+		Problem string `json:"problem"`
+	}
+	var problems []keyProblem
+
+	// Bind lines without an action don't parse as bindings at all, so they
+	// would otherwise vanish from every listing.
+	for _, file := range files {
+		for _, binding := range i3config.Incomplete(file.Lines) {
+			binding.SourceFile = file.Path
+			problems = append(problems, keyProblem{Binding: binding, Problem: fmt.Sprintf("incomplete binding: %s", strings.TrimSpace(binding.Raw))})
+		}
+	}
+	for _, binding := range bindings {
+		if binding.Type == "bindgesture" {
+			continue
+		}
+		for _, problem := range validateKey(binding.Key, binding.IsCode) {
+			problems = append(problems, keyProblem{Binding: binding, Problem: problem})
+		}
+	}
+	if validateExec {
+		vars := parseVariables(lines)
+		for _, binding := range bindings {
+			for _, program := range missingPrograms(binding.Action, vars) {
+				problems = append(problems, keyProblem{Binding: binding, Problem: fmt.Sprintf("%s is not in $PATH", program)})
+			}
+		}
+	}
+	problemCount := len(problems)
+	shadowed := findShadowed(bindings)
+
+	// Variables in exec commands are usually meant for the shell, like
+	// $HOME, so only keys and i3's own commands are checked.
+	type undefinedVariable struct {
+		Binding Binding `json:"binding"`
+		Variable string `json:"variable"`
+	}
+	var undefined []undefinedVariable
+	vars := parseVariables(lines)
+	for _, binding := range bindings {
+		texts := []string{binding.Key}
+		for _, command := range i3config.SplitCommands(binding.Action) {
+			if _, isExec := parseExec(command); !isExec {
+				texts = append(texts, command)
+			}
+		}
+		for _, name := range unresolvedVariables(strings.Join(texts, " "), vars) {
+			undefined = append(undefined, undefinedVariable{Binding: binding, Variable: name})
+		}
+	}
+
+	if jsonOutput {
+		report := map[string]interface{}{
+			"problems": append([]keyProblem{}, problems...),
+			"shadowed": append([]shadowedBinding{}, shadowed...),
+			"undefined_variables": append([]undefinedVariable{}, undefined...),
+		}
+		if problemCount > 0 {
+			printResult("error", fmt.Sprintf("Found %d problem(s) in %s", problemCount, configPath), report)
+			os.Exit(exitError)
+		}
+		printResult("ok", fmt.Sprintf("All %d keybindings look valid", len(bindings)), report)
+		return
+	}
+
+	for _, problem := range problems {
+		errorColor.Printf("%s: ", formatLocation(problem.Binding))
+		fmt.Println(problem.Problem)
+	}
+
+	// Shadowing is often intended, as with Escape inside a mode, so it is
+	// reported without failing validation.
+	for _, shadow := range shadowed {
+		warningColor.Printf("Warning: ")
+		fmt.Printf("%s is bound globally (%s) and in mode %s (%s)\n",
+			formatKey(shadow.Global), formatLocation(shadow.Global),
+			modeColor.Sprint(shadow.InMode.Mode), formatLocation(shadow.InMode))
+	}
+	for _, variable := range undefined {
+		warningColor.Printf("Warning: ")
+		fmt.Printf("%s uses %s, which is never set (%s)\n", formatKey(variable.Binding), variable.Variable, formatLocation(variable.Binding))
+	}
+	if len(shadowed)+len(undefined) > 0 && problemCount == 0 {
+		fmt.Println()
+	}
+
+	if problemCount > 0 {
+		errorColor.Printf("\nFound %d problem(s) in %s\n", problemCount, configPath)
+		os.Exit(exitError)
+	}
+	printSuccess("✓ All %d keybindings look valid\n", len(bindings))
+}
+
+// shadowedBinding pairs a global binding with a binding of the same key
+// inside a mode, which takes over while that mode is active.
+type shadowedBinding struct {
+	Global Binding `json:"global"`
+	InMode Binding `json:"in_mode"`
+}
+
+// findShadowed returns the mode bindings whose key is also bound globally.
+func findShadowed(bindings []Binding) []shadowedBinding {
+	var shadowed []shadowedBinding
+	for _, inMode := range bindings {
+		if inMode.Mode == "" {
+			continue
+		}
+		for _, global := range bindings {
+			if global.Mode == "" && global.Type == inMode.Type && i3config.KeysMatch(global.Key, inMode.Key) {
+				shadowed = append(shadowed, shadowedBinding{Global: global, InMode: inMode})
+				break
+			}
+		}
+	}
+	return shadowed
+}
+
+// findConflicts groups bindings that share a normalized key within the same
+// mode and binding type, keeping only groups with more than one binding.
+func findConflicts(bindings []Binding) [][]Binding {
+	var order []string
+	groups := make(map[string][]Binding)
+	for _, binding := range bindings {
+		id := bindingID(binding)
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], binding)
+	}
+
+	var conflicts [][]Binding
+	for _, id := range order {
+		if len(groups[id]) > 1 {
+			conflicts = append(conflicts, groups[id])
+		}
+	}
+	return conflicts
+}
+
+func listConflicts(cmd *cobra.Command, args []string) {
+	_, bindings, err := loadBindings()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	conflicts := findConflicts(bindings)
+	if jsonOutput {
+		if len(conflicts) > 0 {
+			printResult("error", fmt.Sprintf("Found %d conflicting key(s) in %s", len(conflicts), configPath), conflicts)
+			os.Exit(exitError)
+		}
+		printResult("ok", "No conflicting keybindings found", [][]Binding{})
+		return
+	}
+	if len(conflicts) == 0 {
+		printSuccess("✓ No conflicting keybindings found\n")
+		return
+	}
+
+	errorColor.Printf("Found %d conflicting key(s) in %s:\n", len(conflicts), configPath)
+	for _, group := range conflicts {
+		fmt.Printf("\n%s", formatKey(group[0]))
+		if group[0].Mode != "" {
+			fmt.Printf(" %s", modeColor.Sprintf("[mode: %s]", group[0].Mode))
+		}
+		fmt.Println()
+		for _, binding := range group {
+			fmt.Printf("  %s %s -> %s\n", color.New(color.FgBlack, color.Bold).Sprintf("%s:", formatLocation(binding)), keyColor.Sprint(binding.Key), actionColor.Sprint(binding.Action))
+		}
+	}
+	fmt.Println("\ni3 uses the last definition of each key")
+	os.Exit(exitError)
+}
+
+func printBindingDetails(binding Binding) {
+	fmt.Printf("\nKeybinding Details:\n")
+	fmt.Printf("  Key: %s\n", formatKey(binding))
+	fmt.Printf("  Action: %s\n", actionColor.Sprint(binding.Action))
+	if binding.Comment != "" {
+		fmt.Printf("  Comment: %s\n", commentColor.Sprint(binding.Comment))
+	}
+	fmt.Printf("  Mode: %s\n", modeColor.Sprint(modeLabel(binding.Mode)))
+	fmt.Printf("  Line: %d\n", binding.Line)
+	if binding.SourceFile != "" {
+		fmt.Printf("  File: %s\n", binding.SourceFile)
+	}
+	fmt.Printf("  Raw: %s\n", binding.Raw)
+}
+
+// withConfigLock runs an interactive edit on the config re-read under its
+// lock, so the edit can't interleave with another i3-bind writing it, and
+// releases the lock before the menu waits for input again.
+func withConfigLock(edit func(files []configFile) error) error {
+	if err := lockConfig(configPath, lockTimeout); err != nil {
+		return err
+	}
+	defer unlockConfig()
+	files, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	return edit(files)
+}
+
+// confirmInteractiveRemove prints the config lines that removing keys would
+// delete and asks once more before the menu removes them. The answer is read
+// from the menu's reader, so input it has already buffered isn't lost.
+func confirmInteractiveRemove(reader *bufio.Reader, bindings []Binding, keys []string) bool {
+	errorColor.Println("\nABOUT TO REMOVE:")
+	for _, key := range keys {
+		for _, binding := range bindings {
+			if !i3config.KeysMatch(binding.Key, key) {
+				continue
+			}
+			fmt.Printf("  %s\n", color.New(color.FgBlack, color.Bold).Sprintf("%s:", formatLocation(binding)))
+			for _, line := range strings.Split(binding.Raw, "\n") {
+				fmt.Printf("    %s\n", strings.TrimSpace(line))
+			}
+		}
+	}
+	fmt.Print("\nRemove for good? [y/N]: ")
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func interactiveMode(cmd *cobra.Command, args []string){
+	if jsonOutput {
+		fatalf(exitError, "interactive mode does not support --json")
+	}
+
+	picker, err := newPicker(pickerName)
+	if err != nil {
+		if pickerName == "auto" {
+			fatalWithHints(exitError, err.Error(), "Install it with: sudo pacman -S fzf # or your package manager")
+		}
+		fatalf(exitError, "%v", err)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	// showDetails names a binding to print once the config has been re-read
+	// after an edit.
+	var showDetails string
+
+	// Re-read the config and show the picker again after every action until
+	// the user quits or cancels the picker. Actions work on the files loaded
+	// for this round, so each round reads the config exactly once.
+	for {
+		files, err := loadConfigFiles()
+		if err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		bindings := allBindings(files)
+
+		if showDetails != "" {
+			if binding, ok := i3config.FindBinding(bindings, showDetails); ok {
+				printBindingDetails(binding)
+				fmt.Println()
+			}
+			showDetails = ""
+		}
+
+		if len(bindings) == 0 {
+			fmt.Println("No keybindings found in config file")
+			return
+		}
+
+		selectedKeys, err := picker.Pick(bindings)
+		if err != nil {
+			errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if len(selectedKeys) == 0 {
+			return
+		}
+
+		if len(selectedKeys) > 1 {
+			fmt.Printf("\nSelected %d keybindings:\n", len(selectedKeys))
+			for _, key := range selectedKeys {
+				fmt.Printf("  %s\n", keyColor.Sprint(key))
+			}
+			fmt.Println("\nWhat would you like to do?")
+			fmt.Println("1. Remove these keybindings")
+			fmt.Println("2. Cancel")
+			fmt.Println("3. Quit")
+
+			fmt.Print("\nEnter your choice (1-3): ")
+			choice, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch strings.TrimSpace(choice) {
+			case "1":
+				if !confirmInteractiveRemove(reader, bindings, selectedKeys) {
+					fmt.Println("Cancelled")
+					break
+				}
+				err = withConfigLock(func(files []configFile) error { return removeKeys(files, selectedKeys) })
+			case "2":
+				fmt.Println("Cancelled")
+			case "3":
+				return
+			default:
+				fmt.Println("Invalid choice")
+			}
+			if err != nil {
+				errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			fmt.Println()
+			continue
+		}
+
+		selectedKey := selectedKeys[0]
+
+		fmt.Printf("\nSelected keybinding: %s\n", keyColor.Sprint(selectedKey))
+		fmt.Println("\nWhat would you like to do?")
+		fmt.Println("1. Remove this keybinding")
+		fmt.Println("2. Edit action")
+		fmt.Println("3. Add/Update comment")
+		fmt.Println("4. Show details")
+		fmt.Println("5. Cancel")
+		fmt.Println("6. Quit")
+
+		fmt.Print("\nEnter your choice (1-6): ")
+		choice, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "1":
+			if !confirmInteractiveRemove(reader, bindings, []string{selectedKey}) {
+				fmt.Println("Cancelled")
+				break
+			}
+			err = withConfigLock(func(files []configFile) error { return removeKeys(files, []string{selectedKey}) })
+		case "2":
+			fmt.Print("Enter new action: ")
+			action, _ := reader.ReadString('\n')
+			action = strings.TrimSpace(action)
+			if action == "" {
+				fmt.Println("Cancelled")
+				break
+			}
+			if err = withConfigLock(func(files []configFile) error { return editKey(files, selectedKey, action) }); err == nil {
+				showDetails = selectedKey
+			}
+		case "3":
+			fmt.Print("Enter comment: ")
+			comment, _ := reader.ReadString('\n')
+			comment = strings.TrimSpace(comment)
+			if comment != "" {
+				err = withConfigLock(func(files []configFile) error { return commentKey(files, selectedKey, comment) })
+			}
+		case "4":
+			for _, binding := range bindings {
+				if binding.Key == selectedKey {
+					printBindingDetails(binding)
+					break
+				}
+			}
+		case "5":
+			fmt.Println("Cancelled")
+		case "6":
+			return
+		default:
+			fmt.Println("Invalid choice")
+		}
+		if err != nil {
+			errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Println()
+	}
 }
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"i3-bind/pkg/i3config"
+)
+
+// useTestConfig writes content to a config in a temp directory and points
+// the CLI's global state at it, without output or backups left behind.
+func useTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	t.Setenv("I3_BIND_BACKUP_DIR", "")
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldPath, oldQuiet := configPath, quiet
+	configPath, quiet = path, true
+	t.Cleanup(func() { configPath, quiet = oldPath, oldQuiet })
+	return path
+}
+
+func readTestConfig(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestXDGConfigHome(t *testing.T) {
+	tests := []struct {
+		env string
+		want string
+	}{
+		{"", "/home/u/.config"},
+		{"/tmp/xdg", "/tmp/xdg"},
+		{"relative/dir", "/home/u/.config"},
+	}
+	for _, test := range tests {
+		t.Setenv("XDG_CONFIG_HOME", test.env)
+		if got := xdgConfigHome("/home/u"); got != test.want {
+			t.Errorf("XDG_CONFIG_HOME=%q: got %q, want %q", test.env, got, test.want)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	t.Setenv("XDG_CONFIG_DIRS", "/etc/a:relative:/etc/b")
+	want := []string{"/tmp/xdg/i3/config", "/etc/a/i3/config", "/etc/b/i3/config", "/home/u/.i3/config", "/etc/i3/config"}
+	if got := configCandidates("/home/u", "i3"); !reflect.DeepEqual(got, want) {
+		t.Errorf("configCandidates = %q, want %q", got, want)
+	}
+}
+
+func TestWriteConfigFileKeepsFormat(t *testing.T) {
+	tests := map[string]string{
+		"lf": "bindsym $mod+q kill\n",
+		"no final newline": "bindsym $mod+q kill",
+		"crlf": "bindsym $mod+q kill\r\n",
+		"bom": "\xef\xbb\xbfbindsym $mod+q kill\n",
+	}
+	for name, content := range tests {
+		path := useTestConfig(t, content)
+		lines, err := readConfigFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeConfigFile(path, lines); err != nil {
+			t.Fatal(err)
+		}
+		if got := readTestConfig(t, path); got != content {
+			t.Errorf("%s: wrote %q, want %q", name, got, content)
+		}
+		backups, err := listBackups(path)
+		if err != nil || len(backups) != 1 {
+			t.Errorf("%s: backups = %v, %v", name, backups, err)
+		}
+	}
+}
+
+func TestWriteConfigFileNoBackup(t *testing.T) {
+	path := useTestConfig(t, "bindsym $mod+q kill\n")
+	noBackup = true
+	defer func() { noBackup = false }()
+
+	if err := writeConfigFile(path, []string{"bindsym $mod+q exec xkill"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestConfig(t, path); got != "bindsym $mod+q exec xkill\n" {
+		t.Errorf("wrote %q", got)
+	}
+	if backups, err := listBackups(path); err != nil || len(backups) != 0 {
+		t.Errorf("backups = %v, %v", backups, err)
+	}
+}
+
+func TestRemoveKeysExactMatch(t *testing.T) {
+	path := useTestConfig(t, "bindsym $mod+1 workspace 1\nbindsym $mod+10 workspace 10\nbindsym $mod+11 workspace 11\n")
+	files, err := loadConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := removeKeys(files, []string{"$mod+1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestConfig(t, path), "bindsym $mod+10 workspace 10\nbindsym $mod+11 workspace 11\n"; got != want {
+		t.Errorf("config = %q, want %q", got, want)
+	}
+	if err := removeKeys(files, []string{"$mod+2"}); exitCode(err) != exitNotFound {
+		t.Errorf("removing a missing key: %v", err)
+	}
+}
+
+func TestRemoveKeysWithFlags(t *testing.T) {
+	path := useTestConfig(t, "bindsym --release $mod+x exec a\nbindsym --border --whole-window $mod+y exec b\nbindsym $mod+z exec c\n")
+	files, err := loadConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := removeKeys(files, []string{"$mod+x", "$mod+y"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestConfig(t, path), "bindsym $mod+z exec c\n"; got != want {
+		t.Errorf("config = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveKeysReportsEveryBinding(t *testing.T) {
+	useTestConfig(t, "bindsym $mod+d exec dmenu_run\nmode \"resize\" {\n\tbindsym $mod+d mode \"default\"\n}\n")
+	files, err := loadConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonOutput, messages = true, nil
+	defer func() { jsonOutput, messages = false, nil }()
+
+	if err := removeKeys(files, []string{"$mod+d", "$mod+D"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Removed keybinding: $mod+d -> exec dmenu_run", `Removed keybinding: $mod+d -> mode "default"`}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestTabSeparatedBindings(t *testing.T) {
+	path := useTestConfig(t, "mode \"resize\" {\n\tbindsym\tLeft\tresize shrink width 10 px\n\tbindsym\tRight\tresize grow width 10 px\n}\n")
+	files, err := loadConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindings := allBindings(files)
+	if len(bindings) != 2 || bindings[0].Key != "Left" || bindings[0].Mode != "resize" {
+		t.Fatalf("bindings = %+v", bindings)
+	}
+	if err := removeKeys(files, []string{"Left"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestConfig(t, path), "mode \"resize\" {\n\tbindsym\tRight\tresize grow width 10 px\n}\n"; got != want {
+		t.Errorf("config = %q, want %q", got, want)
+	}
+}
+
+func TestEditKeepsInlineComment(t *testing.T) {
+	path := useTestConfig(t, "bindsym $mod+q kill # close window\n")
+	files, err := loadConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editKey(files, "$mod+q", "exec xkill"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestConfig(t, path), "bindsym $mod+q exec xkill # close window\n"; got != want {
+		t.Errorf("config = %q, want %q", got, want)
+	}
+}
+
+func TestRunAddEditRemove(t *testing.T) {
+	path := useTestConfig(t, "set $mod Mod4\nbindsym $mod+q kill\n")
+
+	if err := runAdd([]string{"$mod+d", "exec", "dmenu_run"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runEdit([]string{"$mod+d", "exec rofi -show drun"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRemove([]string{"$mod+q"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestConfig(t, path), "set $mod Mod4\nbindsym $mod+d exec rofi -show drun\n"; got != want {
+		t.Errorf("config = %q, want %q", got, want)
+	}
+
+	addAfter = "$mod+x"
+	err := runAdd([]string{"$mod+b", "exec firefox"})
+	addAfter = ""
+	if exitCode(err) != exitNotFound {
+		t.Errorf("add --after a missing key: %v", err)
+	}
+	strictKeys = true
+	err = runAdd([]string{"$super+b", "exec firefox"})
+	strictKeys = false
+	if err == nil || exitCode(err) != exitError {
+		t.Errorf("add --strict with an unset variable: %v", err)
+	}
+	if err := runEdit([]string{"$mod+q", "kill"}); exitCode(err) != exitNotFound {
+		t.Errorf("editing a removed key: %v", err)
+	}
+	if err := runRemove([]string{"$mod+q"}, true); exitCode(err) != exitNotFound {
+		t.Errorf("remove --release of a missing key: %v", err)
+	}
+}
+
+func TestReadConfigFileStripsBOM(t *testing.T) {
+	path := useTestConfig(t, "\xef\xbb\xbfbindsym $mod+q kill\n")
+	lines, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindings := i3config.ParseLines(lines)
+	if len(bindings) != 1 || bindings[0].Key != "$mod+q" {
+		t.Errorf("bindings = %+v", bindings)
+	}
+}
+
+func TestImportPruneRemovesComment(t *testing.T) {
+	path := useTestConfig(t, "# terminal\nbindsym $mod+Return exec alacritty\n# launcher\nbindsym $mod+d exec dmenu_run\nbindsym $mod+q kill\n")
+	importFile := filepath.Join(t.TempDir(), "bindings.yaml")
+	if err := os.WriteFile(importFile, []byte("- key: $mod+q\n  action: kill\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	importPrune = true
+	defer func() { importPrune = false }()
+
+	importBindings(nil, []string{importFile})
+	if got, want := readTestConfig(t, path), "bindsym $mod+q kill\n"; got != want {
+		t.Errorf("config after prune = %q, want %q", got, want)
+	}
+}
+
+func TestToggleBinding(t *testing.T) {
+	content := "bindsym $mod+q kill\n"
+	path := useTestConfig(t, content)
+	for _, enable := range []bool{false, true} {
+		files, err := loadConfigFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := toggleBinding(files, "$mod+q", enable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readTestConfig(t, path); got != content {
+		t.Errorf("config after disable and enable = %q, want %q", got, content)
+	}
+	files, _ := loadConfigFiles()
+	if err := toggleBinding(files, "$mod+q", true); exitCode(err) != exitNotFound {
+		t.Errorf("enabling an enabled binding: %v", err)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	sections, err := parseTOML("no-color = true # plain output\nmax-backups = 3\npicker = 'rofi'\n\n[templates]\nterm = \"exec --no-startup-id \\\"kitty\\\"\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tomlSections{
+		"": {"no-color": true, "max-backups": int64(3), "picker": "rofi"},
+		"templates": {"term": `exec --no-startup-id "kitty"`},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("parseTOML = %v, want %v", sections, want)
+	}
+
+	for _, content := range []string{"picker = rofi\n", "[templates\n", "term = \"unterminated\n"} {
+		if _, err := parseTOML(content); err == nil {
+			t.Errorf("parseTOML(%q) succeeded", content)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	for text, want := range map[string]string{"90m": "1h30m0s", "2d": "48h0m0s"} {
+		got, err := parseSince(text)
+		if err != nil || got.String() != want {
+			t.Errorf("parseSince(%q) = %v, %v, want %s", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"", "x", "-1h", "1.5d"} {
+		if _, err := parseSince(text); err == nil {
+			t.Errorf("parseSince(%q) succeeded", text)
+		}
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	tests := []struct {
+		text string
+		width int
+		want string
+	}{
+		{"kill", 6, "kill  "},
+		{"exec firefox", 8, "exec fi…"},
+		{"exec ünïcode", 12, "exec ünïcode"},
+		{"exec ünïcode", 6, "exec …"},
+	}
+	for _, test := range tests {
+		if got := fitColumn(test.text, test.width); got != test.want {
+			t.Errorf("fitColumn(%q, %d) = %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestSameAction(t *testing.T) {
+	defer func() { ignoreExecFlags = false }()
+
+	tests := []struct {
+		a, b string
+		ignore, want bool
+	}{
+		{"exec --no-startup-id firefox", "exec firefox", false, false},
+		{"exec --no-startup-id firefox", "exec firefox", true, true},
+		{"exec_always --no-startup-id nm-applet", "exec_always nm-applet", true, true},
+		{"exec_always --no-startup-id nm-applet", "exec nm-applet", true, false},
+		{"exec --no-startup-id firefox", "exec chromium", true, false},
+		{"kill", "kill", true, true},
+	}
+	for _, test := range tests {
+		ignoreExecFlags = test.ignore
+		if got := sameAction(test.a, test.b); got != test.want {
+			t.Errorf("sameAction(%q, %q) with ignore=%v = %v, want %v", test.a, test.b, test.ignore, got, test.want)
+		}
+	}
+}
+
+func TestSideText(t *testing.T) {
+	binding := Binding{Key: "$mod+r", Action: "mode \"resize\"", Comment: "resize", Mode: "default"}
+	if got, want := sideText(binding), `$mod+r [mode: default] -> mode "resize" # resize`; got != want {
+		t.Errorf("sideText = %q, want %q", got, want)
+	}
+}
+
+func TestParseIndent(t *testing.T) {
+	for text, want := range map[string]string{"0": "", "4": "    ", "tab": "\t", "TAB": "\t"} {
+		if got, err := parseIndent(text); err != nil || got != want {
+			t.Errorf("parseIndent(%q) = %q, %v, want %q", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"-1", "x", ""} {
+		if _, err := parseIndent(text); err == nil {
+			t.Errorf("parseIndent(%q) succeeded", text)
+		}
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"mod4+return": "Mod4+Return",
+		"mod1+shift+q": "Mod1+Shift+q",
+		"ctrl+Q": "Ctrl+Q",
+		"$mod+control+page_up": "$mod+Control+Page_Up",
+		"$mod+kp_enter": "$mod+KP_Enter",
+		"Mod4+f1": "Mod4+F1",
+		"mod4+5": "Mod4+5",
+		"hyper+nosuchkey": "hyper+nosuchkey",
+	} {
+		if got := canonicalKey(key); got != want {
+			t.Errorf("canonicalKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestMatchesSearch(t *testing.T) {
+	binding := Binding{Key: "$mod+Return", Action: "exec alacritty", Comment: "terminal"}
+	defer func() { findKeyOnly, findActionOnly, findCommentOnly = false, false, false }()
+
+	tests := []struct {
+		key, action, comment bool
+		term string
+		want bool
+	}{
+		{false, false, false, "term", true},
+		{false, false, false, "return", true},
+		{true, false, false, "term", false},
+		{false, true, false, "alacritty", true},
+		{false, true, false, "return", false},
+		{false, false, true, "term", true},
+		{true, false, true, "return", true},
+		{true, false, true, "alacritty", false},
+	}
+	for _, test := range tests {
+		findKeyOnly, findActionOnly, findCommentOnly = test.key, test.action, test.comment
+		if got := matchesSearch(binding, test.term); got != test.want {
+			t.Errorf("matchesSearch(%q) with key=%v action=%v comment=%v = %v, want %v", test.term, test.key, test.action, test.comment, got, test.want)
+		}
+	}
+}
+
+func TestConfirmInteractiveRemove(t *testing.T) {
+	bindings := []Binding{{Key: "$mod+d", Action: "exec dmenu_run", Raw: "bindsym $mod+d exec dmenu_run", Line: 3}}
+	for answer, want := range map[string]bool{
+		"y\n": true,
+		"YES\n": true,
+		"\n": false,
+		"n\n": false,
+		"1\n": false,
+		"": false,
+	} {
+		reader := bufio.NewReader(strings.NewReader(answer))
+		if got := confirmInteractiveRemove(reader, bindings, []string{"$mod+d"}); got != want {
+			t.Errorf("confirmInteractiveRemove with answer %q = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+func TestWithConfigLock(t *testing.T) {
+	path := useTestConfig(t, "bindsym $mod+q kill\n")
+	err := withConfigLock(func(files []configFile) error {
+		if configLock == nil {
+			t.Error("lock not held during the edit")
+		}
+		return removeKeys(files, []string{"$mod+q"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configLock != nil {
+		t.Error("lock still held after the edit")
+	}
+	if got := readTestConfig(t, path); got != "\n" {
+		t.Errorf("config after remove = %q", got)
+	}
+}
+
+func TestLimitBindings(t *testing.T) {
+	bindings := []Binding{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+	tests := []struct {
+		limit, wantLen, wantHidden int
+	}{
+		{0, 3, 0},
+		{2, 2, 1},
+		{3, 3, 0},
+		{5, 3, 0},
+	}
+	for _, test := range tests {
+		got, hidden := limitBindings(bindings, test.limit)
+		if len(got) != test.wantLen || hidden != test.wantHidden {
+			t.Errorf("limitBindings(%d) = %d bindings, %d hidden, want %d, %d", test.limit, len(got), hidden, test.wantLen, test.wantHidden)
+		}
+	}
+}
+
+func TestUnresolvedVariables(t *testing.T) {
+	vars := parseVariables([]string{"set $alt Mod1", "set $super $alt", "set_from_resource $term i3wm.term xterm"})
+	tests := map[string][]string{
+		"$alt+x": nil,
+		"$super+Return": nil,
+		"$mod+x": {"$mod"},
+		"$mod+$left": {"$mod", "$left"},
+		"exec $term": nil,
+	}
+	for text, want := range tests {
+		if got := unresolvedVariables(text, vars); !reflect.DeepEqual(got, want) {
+			t.Errorf("unresolvedVariables(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestWatchTargets(t *testing.T) {
+	path := useTestConfig(t, "include conf.d/*.conf\nbindsym $mod+q kill\n")
+	dir := filepath.Dir(path)
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	extra := filepath.Join(dir, "conf.d", "apps.conf")
+	if err := os.WriteFile(extra, []byte("bindsym $mod+b exec firefox\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, dirs, err := watchTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paths[path] || !paths[extra] || len(paths) != 2 {
+		t.Errorf("paths = %v, want %s and %s", paths, path, extra)
+	}
+	if want := []string{dir, filepath.Dir(extra)}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs = %q, want %q", dirs, want)
+	}
+}
+
+func TestWatchTargetsSymlink(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "i3config")
+	if err := os.WriteFile(target, []byte("bindsym $mod+q kill\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := useTestConfig(t, "")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, dirs, err := watchTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paths[path] || !paths[target] {
+		t.Errorf("paths = %v, want %s and %s", paths, path, target)
+	}
+	if want := []string{filepath.Dir(path), filepath.Dir(target)}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs = %q, want %q", dirs, want)
+	}
+}
+
+func TestModifierName(t *testing.T) {
+	for token, want := range map[string]string{
+		"SHIFT": "Shift",
+		"ctrl": "Control",
+		"mod4": "Mod4",
+		"$mod": "$mod",
+		"égrave": "Égrave",
+		"ωmega": "Ωmega",
+	} {
+		if got := modifierName(token); got != want || !utf8.ValidString(got) {
+			t.Errorf("modifierName(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	data, err := json.Marshal(collectStats(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"total":0,"byModifier":{},"execCount":0,"builtinCount":0,"commentedCount":0,"inModes":0,"modes":{},"programs":{}}`
+	if string(data) != want {
+		t.Errorf("stats = %s, want %s", data, want)
+	}
+
+	stats := collectStats([]Binding{
+		{Key: "$mod+Shift+Return", Action: "exec alacritty", Comment: "terminal"},
+		{Key: "h", Action: "resize shrink width 10 px", Mode: "resize"},
+	})
+	if stats.ByModifier["$mod"] != 1 || stats.ByModifier["Shift"] != 1 || stats.ExecCount != 1 || stats.CommentedCount != 1 || stats.Modes["resize"] != 1 || stats.Programs["alacritty"] != 1 {
+		t.Errorf("collectStats = %+v", stats)
+	}
+}
+
+func TestModeTransitionsVariableMode(t *testing.T) {
+	lines := strings.Split(`set $mode_system "System (l) lock, (e) logout"
+bindsym $mod+Pause mode "$mode_system"
+mode "$mode_system" {
+	bindsym l exec i3lock, mode "default"
+	bindsym Escape mode "default"
+}`, "\n")
+	modes, edges := modeTransitions(i3config.ParseLines(lines), parseVariables(lines))
+
+	system := "System (l) lock, (e) logout"
+	if want := []string{"default", system}; !reflect.DeepEqual(modes, want) {
+		t.Errorf("modes = %q, want %q", modes, want)
+	}
+	want := []modeEdge{
+		{From: "default", To: system, Keys: []string{"$mod+Pause"}},
+		{From: system, To: "default", Keys: []string{"l", "Escape"}},
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %+v, want %+v", edges, want)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for s, want := range map[string]string{
+		"exec --no-startup-id dmenu_run": `'exec --no-startup-id dmenu_run'`,
+		`mode "resize"`: `'mode "resize"'`,
+		"exec notify-send 'hi'": `'exec notify-send '\''hi'\'''`,
+	} {
+		if got := shellQuote(s); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"return", "return", 0},
+		{"retrun", "return", 2},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"ünï", "uni", 2},
+	}
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestKeyNotFoundError(t *testing.T) {
+	bindings := []Binding{{Key: "$mod+Return"}, {Key: "$mod+Shift+Return"}, {Key: "$mod+d"}, {Key: "$mod+f"}, {Key: "h", Mode: "resize"}, {Key: "$mod+Return", Mode: "resize"}}
+	tests := map[string]string{
+		"$mod+Retrun": "Keybinding $mod+Retrun not found; did you mean $mod+Return?",
+		"$mod+shift+retrn": "Keybinding $mod+shift+retrn not found; did you mean $mod+Shift+Return?",
+		"$mod+g": "Keybinding $mod+g not found; did you mean $mod+d or $mod+f?",
+		"j": "Keybinding j not found",
+		"$mod+Print": "Keybinding $mod+Print not found",
+	}
+	for key, want := range tests {
+		err := keyNotFoundError(bindings, key)
+		if err.Error() != want {
+			t.Errorf("keyNotFoundError(%q) = %q, want %q", key, err, want)
+		}
+		if exitCode(err) != exitNotFound {
+			t.Errorf("keyNotFoundError(%q) exits with %d", key, exitCode(err))
+		}
+	}
+}
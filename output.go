@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// result is the object printed on stdout for every command with --json.
+type result struct {
+	Status string `json:"status"`
+	Message string `json:"message"`
+	Data interface{} `json:"data"`
+}
+
+var (
+	// messages collects success messages in --json mode so they can be
+	// reported in the final result instead of being printed as they happen.
+	messages []string
+	resultPrinted bool
+)
+
+// printResult prints the --json result object. Only the first call prints,
+// so a command that reports its own result isn't followed by the generic one.
+func printResult(status string, message string, data interface{}) {
+	if resultPrinted {
+		return
+	}
+	resultPrinted = true
+
+	if message == "" {
+		message = strings.Join(messages, "\n")
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result{Status: status, Message: message, Data: data}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode JSON: %v\n", err)
+		os.Exit(exitError)
+	}
+}
+
+// printSuccess prints a success message unless --quiet is set. With --json
+// the message is kept for the result object instead.
+func printSuccess(format string, a ...interface{}) {
+	if jsonOutput {
+		messages = append(messages, strings.TrimSpace(strings.TrimPrefix(fmt.Sprintf(format, a...), "✓ ")))
+		return
+	}
+	if quiet {
+		return
+	}
+	successColor.Printf(format, a...)
+}
+
+// printInfo prints an informational line unless --quiet or --json is set.
+func printInfo(format string, a ...interface{}) {
+	if !quiet && !jsonOutput {
+		fmt.Printf(format, a...)
+	}
+}
+
+// printVerbose prints a --verbose detail line to stderr, so it never mixes
+// with the output of a command or its --json result.
+func printVerbose(format string, a ...interface{}) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, "  "+format+"\n", a...)
+	}
+}
+
+// fatalf reports an error and exits with code.
+func fatalf(code int, format string, a ...interface{}) {
+	fatalWithHints(code, fmt.Sprintf(format, a...))
+}
+
+// fatalWithHints reports an error followed by lines suggesting what to do
+// about it, and exits with code. With --json the error is reported as the
+// result object on stdout and the hints are dropped.
+func fatalWithHints(code int, message string, hints ...string) {
+	if jsonOutput {
+		printResult("error", message, nil)
+		os.Exit(code)
+	}
+	errorColor.Fprintf(os.Stderr, "Error: %s\n", message)
+	for _, hint := range hints {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+	os.Exit(code)
+}
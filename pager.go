@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// terminalHeight returns the number of rows of the terminal, falling back
+// to $LINES and then to 24.
+func terminalHeight() int {
+	sttyCmd := exec.Command("stty", "size")
+	sttyCmd.Stdin = os.Stdin
+	if output, err := sttyCmd.Output(); err == nil {
+		if fields := strings.Fields(string(output)); len(fields) == 2 {
+			if rows, err := strconv.Atoi(fields[0]); err == nil && rows > 0 {
+				return rows
+			}
+		}
+	}
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		return rows
+	}
+	return 24
+}
+
+// withPager runs print and shows what it writes to stdout through $PAGER, or
+// less -R to keep colors, when stdout is a terminal and the output doesn't
+// fit on the screen. With --no-pager, --json or piped output, print writes
+// to stdout directly.
+func withPager(print func()) {
+	if noPager || jsonOutput || !isTerminal(os.Stdout) {
+		print()
+		return
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		print()
+		return
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	var output bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&output, reader)
+		close(done)
+	}()
+
+	print()
+	writer.Close()
+	os.Stdout = stdout
+	<-done
+
+	if bytes.Count(output.Bytes(), []byte("\n")) < terminalHeight() {
+		stdout.Write(output.Bytes())
+		return
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+	pagerCmd := exec.Command(pager[0], pager[1:]...)
+	pagerCmd.Stdin = &output
+	pagerCmd.Stdout = stdout
+	pagerCmd.Stderr = os.Stderr
+	if err := pagerCmd.Run(); err != nil && output.Len() > 0 {
+		if _, ok := err.(*exec.ExitError); !ok {
+			// The pager could not be started, so print the output as is.
+			stdout.Write(output.Bytes())
+		}
+	}
+}
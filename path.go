@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+type configPathInfo struct {
+	Path string `json:"path"`
+	Exists bool `json:"exists"`
+	Source string `json:"source"`
+	Includes []string `json:"includes"`
+}
+
+// showConfigPath prints the config i3-bind resolved to and the included
+// files it reads with it. It exits with exitConfigMissing when the config
+// doesn't exist, so scripts can test for it.
+func showConfigPath(cmd *cobra.Command, args []string) {
+	info := configPathInfo{Path: configPath, Source: "search order", Includes: []string{}}
+	if flag := cmd.Flag("config"); flag != nil && flag.Changed {
+		info.Source = "--config"
+	}
+	if configPath != "-" {
+		if absPath, err := filepath.Abs(configPath); err == nil {
+			info.Path = absPath
+		}
+		stat, err := os.Stat(configPath)
+		info.Exists = err == nil && !stat.IsDir()
+	}
+
+	if info.Exists {
+		files, err := loadConfigFiles()
+		if err != nil {
+			fatalf(exitCode(err), "%v", err)
+		}
+		for _, file := range files[1:] {
+			if absPath, err := filepath.Abs(file.Path); err == nil {
+				info.Includes = append(info.Includes, absPath)
+			} else {
+				info.Includes = append(info.Includes, file.Path)
+			}
+		}
+	}
+
+	if jsonOutput {
+		status := "ok"
+		if !info.Exists {
+			status = "error"
+		}
+		printResult(status, "", info)
+	} else {
+		fmt.Println(info.Path)
+		if info.Exists {
+			printInfo("  exists, from %s\n", info.Source)
+		} else if info.Source == "--config" {
+			printInfo("  %s, from --config\n", errorColor.Sprint("does not exist"))
+		} else {
+			printInfo("  %s; no config was found in the search order\n", errorColor.Sprint("does not exist"))
+		}
+		if len(info.Includes) > 0 {
+			printInfo("  includes:\n")
+			for _, include := range info.Includes {
+				printInfo("    %s\n", include)
+			}
+		}
+	}
+	if !info.Exists {
+		os.Exit(exitConfigMissing)
+	}
+}
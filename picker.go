@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// picker shows the bindings in a selection menu and returns the keys the
+// user picked. A cancelled menu returns no keys and no error.
+type picker interface {
+	Pick(bindings []Binding) ([]string, error)
+}
+
+// newPicker returns the picker for --picker. auto uses the first of fzf,
+// rofi and dmenu found in PATH.
+func newPicker(name string) (picker, error) {
+	pickers := map[string]picker{
+		"fzf": fzfPicker{},
+		"rofi": rofiPicker{},
+		"dmenu": dmenuPicker{},
+	}
+
+	if name == "auto" {
+		for _, candidate := range []string{"fzf", "rofi", "dmenu"} {
+			if _, err := exec.LookPath(candidate); err == nil {
+				return pickers[candidate], nil
+			}
+		}
+		return nil, fmt.Errorf("interactive mode requires fzf, rofi or dmenu to be installed")
+	}
+
+	p, ok := pickers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown picker %s (expected auto, fzf, rofi or dmenu)", name)
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", name)
+	}
+	return p, nil
+}
+
+// exitedWith reports whether err is the picker exiting with one of codes,
+// which is how the pickers signal a cancelled selection.
+func exitedWith(err error, codes ...int) bool {
+	exitError, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if exitError.ExitCode() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// menuLine formats a binding as a single line for pickers without columns.
+func menuLine(binding Binding) string {
+	line := binding.Key + " -> " + binding.Action
+	if binding.Comment != "" {
+		line += " # " + binding.Comment
+	}
+	return line
+}
+
+// bindingPath returns the file a binding is defined in, relative to the
+// main config's directory when it lives below it.
+func bindingPath(binding Binding) string {
+	path := binding.SourceFile
+	if path == "" {
+		path = configPath
+	}
+	if rel, err := filepath.Rel(filepath.Dir(configPath), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+type fzfPicker struct{}
+
+func (fzfPicker) Pick(bindings []Binding) ([]string, error) {
+	escapePreview := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		s = strings.ReplaceAll(s, "$", `\$`)
+		return s
+	}
+
+	var fzfLines []string
+	for _, binding := range bindings {
+
+		displayKey := binding.Key
+		action := binding.Action
+		comment := binding.Comment
+
+		escapedKey := escapePreview(binding.Key)
+		escapedAction := escapePreview(binding.Action)
+		escapedComment := escapePreview(binding.Comment)
+		escapedLocation := escapePreview(bindingPath(binding) + ":" + strconv.Itoa(binding.Line))
+
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s", displayKey, action, comment, escapedKey, escapedAction, escapedComment, escapedLocation)
+
+		fzfLines = append(fzfLines, line)
+	}
+
+	preview := `echo "Key: {4}"; echo "Action: {5}"; if [ -n "{6}" ]; then echo "Comment: {6}"; fi; echo "Location: {7}"`
+	if fzfPreview != "" {
+		preview = fzfPreview
+	}
+	fzfArgs := []string{
+		"--header=i3-bind: Select keybindings to manage (Tab to select several, Ctrl+C to exit)",
+		"--multi",
+		"--with-nth=1,2",
+		"--delimiter=\t",
+		"--preview", preview,
+		"--preview-window=up:4",
+		"--bind=enter:accept",
+		"--height=" + fzfHeight,
+	}
+	if fzfLayout != "" {
+		fzfArgs = append(fzfArgs, "--layout="+fzfLayout)
+	}
+	// fzf lets later options override earlier ones, so user options
+	// from the environment come last.
+	fzfArgs = append(fzfArgs, strings.Fields(os.Getenv("I3_BIND_FZF_OPTS"))...)
+
+	fzfCmd := exec.Command("fzf", fzfArgs...)
+
+	fzfCmd.Stdin = strings.NewReader(strings.Join(fzfLines, "\n"))
+	fzfCmd.Stderr = os.Stderr
+
+	output, err := fzfCmd.Output()
+	if err != nil {
+		if exitedWith(err, 1, 130) { // no match or Ctrl+C
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fzf error: %v", err)
+	}
+
+	var keys []string
+	for _, selected := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if selected == "" {
+			continue
+		}
+		columns := strings.Split(selected, "\t")
+		keys = append(keys, columns[0])
+	}
+	return keys, nil
+}
+
+type rofiPicker struct{}
+
+// Pick asks rofi for the indexes of the selected lines, so the result does
+// not depend on how the line was displayed.
+func (rofiPicker) Pick(bindings []Binding) ([]string, error) {
+	var lines []string
+	for _, binding := range bindings {
+		lines = append(lines, menuLine(binding))
+	}
+
+	rofiCmd := exec.Command("rofi", "-dmenu", "-i", "-multi-select", "-format", "i", "-p", "i3-bind")
+	rofiCmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+	rofiCmd.Stderr = os.Stderr
+
+	output, err := rofiCmd.Output()
+	if err != nil {
+		if exitedWith(err, 1) { // Escape
+			return nil, nil
+		}
+		return nil, fmt.Errorf("rofi error: %v", err)
+	}
+
+	var keys []string
+	for _, selected := range strings.Fields(string(output)) {
+		index, err := strconv.Atoi(selected)
+		if err != nil || index < 0 || index >= len(bindings) {
+			continue
+		}
+		keys = append(keys, bindings[index].Key)
+	}
+	return keys, nil
+}
+
+type dmenuPicker struct{}
+
+// Pick maps the lines dmenu prints back to bindings by their text, since
+// dmenu has no way to report indexes.
+func (dmenuPicker) Pick(bindings []Binding) ([]string, error) {
+	var lines []string
+	byLine := make(map[string]string)
+	for _, binding := range bindings {
+		line := menuLine(binding)
+		lines = append(lines, line)
+		if _, ok := byLine[line]; !ok {
+			byLine[line] = binding.Key
+		}
+	}
+
+	dmenuCmd := exec.Command("dmenu", "-i", "-l", "20", "-p", "i3-bind")
+	dmenuCmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+	dmenuCmd.Stderr = os.Stderr
+
+	output, err := dmenuCmd.Output()
+	if err != nil {
+		if exitedWith(err, 1) { // Escape
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dmenu error: %v", err)
+	}
+
+	var keys []string
+	for _, selected := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if key, ok := byLine[selected]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
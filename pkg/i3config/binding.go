@@ -0,0 +1,390 @@
+// Package i3config reads, parses and edits i3 and sway config files. It
+// holds the logic behind the i3-bind command, free of its flags and output,
+// so other programs can list and change keybindings without shelling out.
+package i3config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Binding struct {
+	Key string `json:"key"`
+	Action string `json:"action"`
+	Comment string `json:"comment"`
+	InlineComment bool `json:"inline_comment"`
+	Line int `json:"line"`
+	EndLine int `json:"end_line"`
+	Raw string `json:"raw"`
+	IsCode bool `json:"is_code"`
+	// Type is the directive the binding was declared with: bindsym,
+	// bindcode or bindgesture.
+	Type string `json:"type"`
+	Flags []string `json:"flags,omitempty"`
+	// ModeSwitch is the mode the action switches to, as written in the
+	// config (a name or a $variable), or empty if it doesn't switch modes.
+	ModeSwitch string `json:"mode_switch,omitempty"`
+	Mode string `json:"mode"`
+	// Section is the nearest section header comment above the binding,
+	// such as "Applications" for "# Applications:".
+	Section string `json:"section,omitempty"`
+	SourceFile string `json:"source_file"`
+	Disabled bool `json:"disabled"`
+}
+
+var (
+	// Flags such as --release or --whole-window sit between the bind
+	// command and the key; the last group holds the action with any
+	// inline comment, which CommentIndex splits off.
+	bindRegex = regexp.MustCompile(`^\s*(bindsym|bindcode)((?:\s+--[^\s]+)*)\s+([^\s]+)\s+(.+)$`)
+	// gestureRegex matches sway's bindgesture lines, such as
+	// "bindgesture swipe:right workspace next", with the same groups.
+	gestureRegex = regexp.MustCompile(`^\s*(bindgesture)((?:\s+--[^\s]+)*)\s+([^\s]+)\s+(.+)$`)
+	disabledRegex = regexp.MustCompile(`^(\s*)#\s*\[disabled\]\s*(.*)$`)
+	// modeCommandRegex matches a mode command within an action, capturing
+	// the quoted or bare mode name.
+	modeCommandRegex = regexp.MustCompile(`^mode\s+(?:--pango_markup\s+)?(?:"([^"]*)"|(\S+))$`)
+	// keyTokenRegex captures the key token of a binding line: the first
+	// word after the bind directive and any --flags, ending at whitespace.
+	keyTokenRegex = regexp.MustCompile(`^\s*(?:bindsym|bindcode|bindgesture)(?:\s+--[^\s]+)*\s+([^\s]+)(?:\s|$)`)
+	// directiveRegex matches any line starting with a bind directive,
+	// whether or not it is a complete binding.
+	directiveRegex = regexp.MustCompile(`^\s*(bindsym|bindcode|bindgesture)(?:\s|$)`)
+	modeRegex = regexp.MustCompile(`^\s*mode\s+(?:--pango_markup\s+)?(?:"([^"]*)"|([^\s{]+))\s*\{\s*$`)
+)
+
+// Parse parses the bindings of a config file's content.
+func Parse(data []byte) []Binding {
+	lines, _ := SplitLines(data)
+	return ParseLines(lines)
+}
+
+// ParseLines parses the bindings of a config split into lines.
+func ParseLines(lines []string) []Binding {
+	return ParseLinesWithDisabled(lines, false)
+}
+
+// ParseLinesWithDisabled parses bindings like ParseLines, optionally
+// including bindings switched off with Disable.
+func ParseLinesWithDisabled(lines []string, includeDisabled bool) []Binding {
+	var bindings []Binding
+
+	// blocks holds the mode name of every open {} block, with "" for
+	// non-mode blocks such as bar {}, so closing braces pop the right scope.
+	var blocks []string
+	currentMode := func() string {
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if blocks[i] != "" {
+				return blocks[i]
+			}
+		}
+		return ""
+	}
+
+	section := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if header, ok := SectionHeader(line); ok {
+			section = header
+			continue
+		}
+		if modeMatches := modeRegex.FindStringSubmatch(line); modeMatches != nil {
+			blocks = append(blocks, modeMatches[1]+modeMatches[2])
+			continue
+		}
+		if strings.HasSuffix(trimmed, "{") && !strings.HasPrefix(trimmed, "#") {
+			blocks = append(blocks, "")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "}") {
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+
+		disabled := false
+		if disabledMatches := disabledRegex.FindStringSubmatch(line); disabledMatches != nil {
+			if !includeDisabled {
+				continue
+			}
+			disabled = true
+			line = disabledMatches[1] + disabledMatches[2]
+		}
+
+		line, end := joinContinued(lines, i, line, disabled)
+		if matches := matchBinding(line); matches != nil {
+			action, comment := splitAction(matches[4])
+			inline := comment != ""
+
+			if comment == "" && i > 0 {
+				previousLine := strings.TrimSpace(lines[i-1])
+				if strings.HasPrefix(previousLine, "#") && !disabledRegex.MatchString(previousLine) {
+					trimmed := strings.TrimSpace(strings.TrimPrefix(previousLine, "#"))
+					
+					if !strings.HasSuffix(trimmed, ":") {
+						comment = trimmed
+					}
+				}
+			}
+			binding := Binding{
+				Key: matches[3],
+				Action: action,
+				Comment: comment,
+				InlineComment: inline,
+				Line: i+1,
+				EndLine: end+1,
+				Raw: strings.Join(lines[i:end+1], "\n"),
+				IsCode: matches[1] == "bindcode",
+				Type: matches[1],
+				ModeSwitch: ModeSwitchTarget(action),
+				Flags: strings.Fields(matches[2]),
+				Mode: currentMode(),
+				Section: section,
+				Disabled: disabled,
+			}
+			bindings = append(bindings, binding)
+			i = end
+		}
+	}
+	return bindings
+}
+
+// joinContinued joins the lines continued with a trailing backslash after
+// lines[i] into one logical line, returning it with the index of its last
+// line. Continuations of a disabled binding carry the marker too.
+func joinContinued(lines []string, i int, line string, disabled bool) (string, int) {
+	end := i
+	if !strings.HasPrefix(strings.TrimSpace(line), "bind") {
+		return line, end
+	}
+	for strings.HasSuffix(strings.TrimRight(line, " \t"), "\\") && end+1 < len(lines) {
+		end++
+		next := lines[end]
+		if disabled {
+			if nextMatches := disabledRegex.FindStringSubmatch(next); nextMatches != nil {
+				next = nextMatches[2]
+			}
+		}
+		line = strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " \t"), "\\"), " \t") + " " + strings.TrimSpace(next)
+	}
+	return line, end
+}
+
+// matchBinding matches a complete bind line, one with an action left once
+// its inline comment is removed.
+func matchBinding(line string) []string {
+	matches := bindRegex.FindStringSubmatch(line)
+	if matches == nil {
+		matches = gestureRegex.FindStringSubmatch(line)
+	}
+	if matches == nil {
+		return nil
+	}
+	if action, _ := splitAction(matches[4]); action == "" {
+		return nil
+	}
+	return matches
+}
+
+// splitAction separates the action of a bind line from its inline comment.
+func splitAction(text string) (string, string) {
+	if index := CommentIndex(text); index >= 0 {
+		return strings.TrimSpace(text[:index]), strings.TrimSpace(text[index+1:])
+	}
+	return strings.TrimSpace(text), ""
+}
+
+// Incomplete returns the bind lines that ParseLines skips because they
+// have no action, such as a bare "bindsym $mod+x" or one followed only by
+// a comment. Only Key, Type, IsCode,
+// Line, EndLine, Raw and Mode are set; Key is empty when the line has no key
+// either.
+func Incomplete(lines []string) []Binding {
+	var incomplete []Binding
+	var blocks []string
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if modeMatches := modeRegex.FindStringSubmatch(lines[i]); modeMatches != nil {
+			blocks = append(blocks, modeMatches[1]+modeMatches[2])
+			continue
+		}
+		if strings.HasSuffix(trimmed, "{") && !strings.HasPrefix(trimmed, "#") {
+			blocks = append(blocks, "")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "}") {
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+
+		directiveMatches := directiveRegex.FindStringSubmatch(lines[i])
+		if directiveMatches == nil {
+			continue
+		}
+		line, end := joinContinued(lines, i, lines[i], false)
+		if matchBinding(line) == nil {
+			key, _ := KeyToken(line)
+			mode := ""
+			for j := len(blocks) - 1; j >= 0 && mode == ""; j-- {
+				mode = blocks[j]
+			}
+			incomplete = append(incomplete, Binding{
+				Key: key,
+				Type: directiveMatches[1],
+				IsCode: directiveMatches[1] == "bindcode",
+				Line: i+1,
+				EndLine: end+1,
+				Raw: strings.Join(lines[i:end+1], "\n"),
+				Mode: mode,
+			})
+		}
+		i = end
+	}
+	return incomplete
+}
+
+// CommentIndex returns the index of the # starting an inline comment in
+// text, or -1 if there is none. A # inside single or double quotes, as in
+// exec "notify-send '#1 done'", is part of the command.
+func CommentIndex(text string) int {
+	var quote rune
+	escaped := false
+	for i, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return i
+		}
+	}
+	return -1
+}
+
+// SectionHeader returns the name of a section header comment, a comment
+// ending in ":" such as "# Applications:", which groups the bindings below
+// it.
+func SectionHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") || !strings.HasSuffix(trimmed, ":") || disabledRegex.MatchString(line) {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimLeft(trimmed, "#"), ":"))
+	return name, name != ""
+}
+
+// KeyToken returns the key of the binding declared on line, exactly as
+// written.
+func KeyToken(line string) (string, bool) {
+	matches := keyTokenRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// ModeSwitchTarget returns the mode an action switches to, taking the last
+// mode command when several are chained.
+func ModeSwitchTarget(action string) string {
+	target := ""
+	for _, command := range SplitCommands(action) {
+		if matches := modeCommandRegex.FindStringSubmatch(strings.TrimSpace(command)); matches != nil {
+			target = matches[1] + matches[2]
+		}
+	}
+	return target
+}
+
+// SplitCommands splits an action into the commands chained with ";" or ",",
+// leaving separators inside double quotes alone.
+func SplitCommands(action string) []string {
+	var commands []string
+	quoted := false
+	start := 0
+	for i, r := range action {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ';' || r == ',') && !quoted:
+			commands = append(commands, action[start:i])
+			start = i + 1
+		}
+	}
+	return append(commands, action[start:])
+}
+
+// modifierRank orders modifiers canonically: variables such as $mod first,
+// then Mod1-Mod5, Control and Shift, followed by any other modifier.
+func modifierRank(modifier string) int {
+	switch {
+	case strings.HasPrefix(modifier, "$"):
+		return 0
+	case strings.HasPrefix(modifier, "mod"):
+		return 1
+	case modifier == "control":
+		return 2
+	case modifier == "shift":
+		return 3
+	}
+	return 4
+}
+
+// NormalizeModifier lowercases a modifier and folds aliases such as ctrl
+// into their canonical name.
+func NormalizeModifier(modifier string) string {
+	modifier = strings.ToLower(modifier)
+	if modifier == "ctrl" {
+		return "control"
+	}
+	return modifier
+}
+
+// NormalizeKey returns a canonical form of a key combination so that keys
+// differing only in case or modifier order compare equal.
+func NormalizeKey(key string) string {
+	tokens := strings.Split(strings.ToLower(key), "+")
+	if len(tokens) == 1 {
+		return tokens[0]
+	}
+
+	modifiers := tokens[:len(tokens)-1]
+	for i, modifier := range modifiers {
+		modifiers[i] = NormalizeModifier(modifier)
+	}
+	sort.SliceStable(modifiers, func(i, j int) bool {
+		rankI, rankJ := modifierRank(modifiers[i]), modifierRank(modifiers[j])
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return modifiers[i] < modifiers[j]
+	})
+
+	return strings.Join(append(modifiers, tokens[len(tokens)-1]), "+")
+}
+
+// KeysMatch reports whether two keys refer to the same key combination.
+func KeysMatch(a, b string) bool {
+	return NormalizeKey(a) == NormalizeKey(b)
+}
+
+// FindBinding returns the first binding matching key.
+func FindBinding(bindings []Binding, key string) (Binding, bool) {
+	for _, binding := range bindings {
+		if KeysMatch(binding.Key, key) {
+			return binding, true
+		}
+	}
+	return Binding{}, false
+}
@@ -0,0 +1,232 @@
+package i3config
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleConfig = `# i3 config
+set $mod Mod4
+
+# Applications:
+# terminal
+bindsym $mod+Return exec alacritty
+bindsym $mod+d exec dmenu_run # launcher
+bindsym --release $mod+x exec --no-startup-id xdotool key super
+	bindsym	$mod+Tab	workspace back_and_forth
+bindcode 133 exec rofi -show run
+bindsym $mod+shift+e exec \
+    i3-nagbar -m 'Exit?'
+# [disabled] bindsym $mod+p exec passmenu
+
+# Modes:
+mode "resize" {
+        bindsym h resize shrink width 10 px
+        bindsym Escape mode "default"
+}
+bindsym $mod+r mode "resize"
+bindgesture swipe:right workspace next
+
+bar {
+        status_command i3status
+}
+`
+
+func sampleLines() []string {
+	lines, _ := SplitLines([]byte(sampleConfig))
+	return lines
+}
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		key string
+		want Binding
+	}{
+		{"$mod+Return", Binding{Action: "exec alacritty", Comment: "terminal", Line: 6, EndLine: 6, Type: "bindsym", Section: "Applications"}},
+		{"$mod+d", Binding{Action: "exec dmenu_run", Comment: "launcher", InlineComment: true, Line: 7, EndLine: 7, Type: "bindsym", Section: "Applications"}},
+		{"$mod+x", Binding{Action: "exec --no-startup-id xdotool key super", Line: 8, EndLine: 8, Type: "bindsym", Flags: []string{"--release"}, Section: "Applications"}},
+		{"$mod+Tab", Binding{Action: "workspace back_and_forth", Line: 9, EndLine: 9, Type: "bindsym", Section: "Applications"}},
+		{"133", Binding{Action: "exec rofi -show run", Line: 10, EndLine: 10, IsCode: true, Type: "bindcode", Section: "Applications"}},
+		{"$mod+shift+e", Binding{Action: "exec i3-nagbar -m 'Exit?'", Line: 11, EndLine: 12, Type: "bindsym", Section: "Applications"}},
+		{"h", Binding{Action: "resize shrink width 10 px", Line: 17, EndLine: 17, Type: "bindsym", Mode: "resize", Section: "Modes"}},
+		{"Escape", Binding{Action: `mode "default"`, Line: 18, EndLine: 18, Type: "bindsym", ModeSwitch: "default", Mode: "resize", Section: "Modes"}},
+		{"$mod+r", Binding{Action: `mode "resize"`, Line: 20, EndLine: 20, Type: "bindsym", ModeSwitch: "resize", Section: "Modes"}},
+		{"swipe:right", Binding{Action: "workspace next", Line: 21, EndLine: 21, Type: "bindgesture", Section: "Modes"}},
+	}
+
+	bindings := ParseLines(sampleLines())
+	if len(bindings) != len(tests) {
+		t.Fatalf("parsed %d bindings, want %d: %+v", len(bindings), len(tests), bindings)
+	}
+	for i, test := range tests {
+		got := bindings[i]
+		if got.Key != test.key {
+			t.Errorf("binding %d has key %q, want %q", i, got.Key, test.key)
+			continue
+		}
+		got.Key, got.Raw = "", ""
+		if len(got.Flags) == 0 {
+			got.Flags = nil
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("binding %s = %+v, want %+v", test.key, got, test.want)
+		}
+	}
+}
+
+func TestParseLinesWithDisabled(t *testing.T) {
+	bindings := ParseLinesWithDisabled(sampleLines(), true)
+	binding, ok := FindBinding(bindings, "$mod+p")
+	if !ok || !binding.Disabled || binding.Action != "exec passmenu" {
+		t.Errorf("disabled binding = %+v, %v", binding, ok)
+	}
+	if _, ok := FindBinding(ParseLines(sampleLines()), "$mod+p"); ok {
+		t.Error("ParseLines returned a disabled binding")
+	}
+}
+
+func TestParseStripsBOM(t *testing.T) {
+	bindings := Parse([]byte(utf8BOM + "bindsym $mod+q kill\r\nbindsym $mod+f fullscreen toggle\r\n"))
+	if len(bindings) != 2 || bindings[0].Key != "$mod+q" || bindings[0].Action != "kill" {
+		t.Errorf("Parse = %+v", bindings)
+	}
+}
+
+func TestParseTabs(t *testing.T) {
+	bindings := Parse([]byte("mode \"resize\" {\n\tbindsym\tLeft\tresize shrink width 10 px\t# narrower\n}\n"))
+	if len(bindings) != 1 {
+		t.Fatalf("Parse = %+v", bindings)
+	}
+	if got := bindings[0]; got.Key != "Left" || got.Action != "resize shrink width 10 px" || got.Comment != "narrower" || got.Mode != "resize" {
+		t.Errorf("Parse = %+v", got)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	lines, _ := SplitLines([]byte("bindsym $mod+x\nbindsym $mod+Return exec i3-sensible-terminal\nmode \"resize\" {\n\tbindcode 113\n}\nbindsym\n# bindsym $mod+y\nbindsymbols are not bindings\nbindsym $mod+x  \nbindsym $mod+y # todo\n"))
+	incomplete := Incomplete(lines)
+	if len(incomplete) != 5 {
+		t.Fatalf("Incomplete = %+v", incomplete)
+	}
+	want := []Binding{
+		{Key: "$mod+x", Type: "bindsym", Line: 1, EndLine: 1, Raw: "bindsym $mod+x"},
+		{Key: "113", Type: "bindcode", IsCode: true, Line: 4, EndLine: 4, Raw: "\tbindcode 113", Mode: "resize"},
+		{Type: "bindsym", Line: 6, EndLine: 6, Raw: "bindsym"},
+		{Key: "$mod+x", Type: "bindsym", Line: 9, EndLine: 9, Raw: "bindsym $mod+x  "},
+		{Key: "$mod+y", Type: "bindsym", Line: 10, EndLine: 10, Raw: "bindsym $mod+y # todo"},
+	}
+	for i, got := range incomplete {
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("Incomplete[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if bindings := ParseLines(lines); len(bindings) != 1 {
+		t.Errorf("ParseLines = %+v", bindings)
+	}
+}
+
+func TestSectionHeader(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		ok bool
+	}{
+		{"# Applications:", "Applications", true},
+		{"  ## Window management:", "Window management", true},
+		{"# terminal", "", false},
+		{"# [disabled] bindsym $mod+x exec foo:", "", false},
+		{"bindsym $mod+x exec foo:", "", false},
+		{"#:", "", false},
+	}
+	for _, test := range tests {
+		name, ok := SectionHeader(test.line)
+		if name != test.name || ok != test.ok {
+			t.Errorf("SectionHeader(%q) = %q, %v, want %q, %v", test.line, name, ok, test.name, test.ok)
+		}
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		match bool
+	}{
+		{"$mod+Shift+q", "shift+$mod+Q", true},
+		{"Ctrl+Mod1+x", "mod1+control+x", true},
+		{"$mod+1", "$mod+11", false},
+		{"$mod+1", "$mod+10", false},
+		{"Return", "return", true},
+	}
+	for _, test := range tests {
+		if got := KeysMatch(test.a, test.b); got != test.match {
+			t.Errorf("KeysMatch(%q, %q) = %v, want %v", test.a, test.b, got, test.match)
+		}
+	}
+}
+
+func TestModeSwitchTarget(t *testing.T) {
+	tests := map[string]string{
+		`mode "resize"`: "resize",
+		`mode $mode_system`: "$mode_system",
+		`exec foo; mode "default"`: "default",
+		`exec "echo 'mode x'"`: "",
+		`kill`: "",
+	}
+	for action, want := range tests {
+		if got := ModeSwitchTarget(action); got != want {
+			t.Errorf("ModeSwitchTarget(%q) = %q, want %q", action, got, want)
+		}
+	}
+}
+
+func TestSplitCommands(t *testing.T) {
+	got := SplitCommands(`exec "a; b", kill; mode "default"`)
+	want := []string{`exec "a; b"`, " kill", ` mode "default"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitCommands = %q, want %q", got, want)
+	}
+}
+
+func TestKeyToken(t *testing.T) {
+	for line, want := range map[string]string{
+		"bindsym $mod+1 workspace 1": "$mod+1",
+		"  bindsym --release $mod+11 kill": "$mod+11",
+		"\tbindcode\t133 exec rofi": "133",
+	} {
+		if got, ok := KeyToken(line); !ok || got != want {
+			t.Errorf("KeyToken(%q) = %q, %v, want %q", line, got, ok, want)
+		}
+	}
+	if _, ok := KeyToken("set $mod Mod4"); ok {
+		t.Error("KeyToken matched a set line")
+	}
+}
+
+func TestCommentIndex(t *testing.T) {
+	for text, want := range map[string]int{
+		"kill": -1,
+		"kill # close": 5,
+		"kill#close": 4,
+		`exec "notify-send '#1 done'"`: -1,
+		`exec "notify-send '#1 done'" # notify`: 29,
+		`exec notify-send '#1' # notify`: 22,
+		`exec "say \"#1\"" # quoted`: 18,
+	} {
+		if got := CommentIndex(text); got != want {
+			t.Errorf("CommentIndex(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestParseQuotedHash(t *testing.T) {
+	bindings := Parse([]byte("bindsym $mod+p exec \"notify-send '#1 done'\"\nbindsym $mod+o exec \"notify-send '#2'\" # second\n"))
+	if len(bindings) != 2 {
+		t.Fatalf("Parse = %+v", bindings)
+	}
+	if got := bindings[0]; got.Action != `exec "notify-send '#1 done'"` || got.Comment != "" || got.InlineComment {
+		t.Errorf("Parse = %+v", got)
+	}
+	if got := bindings[1]; got.Action != `exec "notify-send '#2'"` || got.Comment != "second" || !got.InlineComment {
+		t.Errorf("Parse = %+v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package i3config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors for missing keybindings and
+	// modes.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is wrapped by the error for adding a key that is already
+	// bound.
+	ErrExists = errors.New("already exists")
+)
+
+// Config is a single config file loaded into memory. Its methods edit
+// Lines; nothing is written until Save. Files pulled in with include are
+// not followed.
+type Config struct {
+	Path string
+	Lines []string
+	Format Format
+}
+
+// Load reads the config file at path.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines, format := SplitLines(data)
+	return &Config{Path: path, Lines: lines, Format: format}, nil
+}
+
+// Bindings returns the bindings of the config, tagged with its path.
+func (c *Config) Bindings() []Binding {
+	bindings := ParseLines(c.Lines)
+	for i := range bindings {
+		bindings[i].SourceFile = c.Path
+	}
+	return bindings
+}
+
+// Find returns the binding for key in mode, "" being the global scope.
+func (c *Config) Find(key string, mode string) (Binding, bool) {
+	for _, binding := range c.Bindings() {
+		if binding.Mode == mode && KeysMatch(binding.Key, key) {
+			return binding, true
+		}
+	}
+	return Binding{}, false
+}
+
+// Add adds b as a bindsym line, or a bindcode line when b.IsCode is set, at
+// the end of its mode. Key, Action and Mode are used, along with Flags and
+// an inline Comment when set.
+func (c *Config) Add(b Binding) error {
+	if b.Key == "" || strings.TrimSpace(b.Action) == "" {
+		return fmt.Errorf("a keybinding needs a key and an action")
+	}
+	if _, ok := c.Find(b.Key, b.Mode); ok {
+		message := fmt.Sprintf("Keybinding %s", b.Key)
+		if b.Mode != "" {
+			message += " in mode " + b.Mode
+		}
+		return fmt.Errorf("%s %w", message, ErrExists)
+	}
+
+	directive := "bindsym"
+	if b.IsCode {
+		directive = "bindcode"
+	}
+	line := strings.Join(append(append([]string{directive}, b.Flags...), b.Key, b.Action), " ")
+	if b.Comment != "" {
+		line += " # " + b.Comment
+	}
+	lines, err := InsertBinding(c.Lines, line, b.Mode)
+	if err != nil {
+		return err
+	}
+	c.Lines = lines
+	return nil
+}
+
+// Remove removes every binding of key, in any mode, along with their
+// detached comment lines.
+func (c *Config) Remove(key string) error {
+	bindings := c.Bindings()
+	found := false
+	// Bottom up, so removing a binding doesn't move the ones still to go.
+	for i := len(bindings) - 1; i >= 0; i-- {
+		if KeysMatch(bindings[i].Key, key) {
+			c.Lines = RemoveBinding(c.Lines, bindings[i], false)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("Keybinding %s %w", key, ErrNotFound)
+	}
+	return nil
+}
+
+// Edit replaces the action of the first binding of key.
+func (c *Config) Edit(key string, action string) error {
+	binding, ok := FindBinding(c.Bindings(), key)
+	if !ok {
+		return fmt.Errorf("Keybinding %s %w", key, ErrNotFound)
+	}
+	c.Lines = SetBindingAction(c.Lines, binding, action)
+	return nil
+}
+
+// WriteTo writes the config to w in the format it was read with.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(JoinLines(c.Lines, c.format()))
+	return int64(n), err
+}
+
+// Save writes the config back to its path in the format it was read with,
+// keeping the file's permissions.
+func (c *Config) Save() error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(c.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return WriteFile(c.Path, JoinLines(c.Lines, c.format()), perm)
+}
+
+// format returns the config's format, or DefaultFormat for a Config that
+// wasn't loaded from a file.
+func (c *Config) format() Format {
+	if c.Format.LineEnding == "" {
+		return DefaultFormat
+	}
+	return c.Format
+}
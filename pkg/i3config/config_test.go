@@ -0,0 +1,67 @@
+package i3config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("bindsym $mod+1 workspace 1\r\nbindsym $mod+10 workspace 10\r\n# close window\r\nbindsym $mod+q kill\r\nmode \"resize\" {\r\n}\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Add(Binding{Key: "$mod+g", Action: "exec gimp", Comment: "paint"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Add(Binding{Key: "h", Action: "resize shrink width 10 px", Mode: "resize"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Add(Binding{Key: "$mod+Q", Action: "nop"}); !errors.Is(err, ErrExists) {
+		t.Errorf("adding a bound key: %v, want ErrExists", err)
+	}
+	if err := config.Edit("$mod+g", "exec krita"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Remove("$mod+1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Remove("$mod+q"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Remove("$mod+q"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("removing a missing key: %v, want ErrNotFound", err)
+	}
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "bindsym $mod+10 workspace 10\r\nbindsym $mod+g exec krita # paint\r\nmode \"resize\" {\r\n        bindsym h resize shrink width 10 px\r\n}\r\n"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("saved config:\n%q\nwant:\n%q", content, want)
+	}
+
+	var buf bytes.Buffer
+	if _, err := config.WriteTo(&buf); err != nil || buf.String() != want {
+		t.Errorf("WriteTo = %q, %v", buf.String(), err)
+	}
+}
+
+func TestConfigWriteToDefaultFormat(t *testing.T) {
+	config := &Config{Lines: []string{"bindsym $mod+q kill"}}
+	var buf bytes.Buffer
+	if _, err := config.WriteTo(&buf); err != nil || buf.String() != "bindsym $mod+q kill\n" {
+		t.Errorf("WriteTo = %q, %v", buf.String(), err)
+	}
+}
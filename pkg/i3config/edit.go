@@ -0,0 +1,228 @@
+package i3config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FindModeBlock returns the 0-based indexes of the header and closing brace
+// of the named mode block.
+func FindModeBlock(lines []string, name string) (int, int, bool) {
+	for i, line := range lines {
+		matches := modeRegex.FindStringSubmatch(line)
+		if matches == nil || matches[1]+matches[2] != name {
+			continue
+		}
+		depth := 1
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if strings.HasSuffix(trimmed, "{") {
+				depth++
+			}
+			if strings.HasPrefix(trimmed, "}") {
+				depth--
+				if depth == 0 {
+					return i, j, true
+				}
+			}
+		}
+		return i, len(lines), true
+	}
+	return 0, 0, false
+}
+
+// BindingStart returns the 0-based index of the first line belonging to
+// binding, which is its detached comment line when it has one.
+func BindingStart(binding Binding) int {
+	if binding.Comment != "" && !binding.InlineComment && binding.Line > 1 {
+		return binding.Line - 2
+	}
+	return binding.Line - 1
+}
+
+// BindingIndent returns the leading whitespace of line.
+func BindingIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// InsertLine returns lines with newLine inserted at the 0-based index.
+func InsertLine(lines []string, index int, newLine string) []string {
+	return append(lines[:index], append([]string{newLine}, lines[index:]...)...)
+}
+
+// InsertBinding returns lines with newLine added after the last binding of
+// mode, or of the global scope when mode is empty, indented like it.
+func InsertBinding(lines []string, newLine string, mode string) ([]string, error) {
+	index, indent, err := InsertPoint(lines, mode)
+	if err != nil {
+		return nil, err
+	}
+	return InsertLine(append([]string(nil), lines...), index, indent+strings.TrimLeft(newLine, " \t")), nil
+}
+
+// InsertPoint returns the 0-based index InsertBinding adds a binding of mode
+// at, and the indent of the bindings around it. An empty mode block takes
+// the indent of the bindings in other mode blocks, or the 8 spaces of i3's
+// default config when there are none.
+func InsertPoint(lines []string, mode string) (int, string, error) {
+	bindings := ParseLines(lines)
+
+	if mode == "" {
+		index, indent := len(lines), ""
+		for _, binding := range bindings {
+			if binding.Mode == "" {
+				index = binding.EndLine
+				indent = BindingIndent(lines[binding.Line-1])
+			}
+		}
+		return index, indent, nil
+	}
+
+	start, end, ok := FindModeBlock(lines, mode)
+	if !ok {
+		return 0, "", fmt.Errorf("Mode %s %w", mode, ErrNotFound)
+	}
+	index, indent := start+1, ""
+	found := false
+	for _, binding := range bindings {
+		if binding.Mode == mode && binding.Line-1 > start && binding.Line-1 < end {
+			index = binding.EndLine
+			indent = BindingIndent(lines[binding.Line-1])
+			found = true
+		}
+	}
+	if found {
+		return index, indent, nil
+	}
+
+	indent = BindingIndent(lines[start]) + "        "
+	for _, binding := range bindings {
+		if binding.Mode == "" {
+			continue
+		}
+		if otherStart, _, ok := FindModeBlock(lines, binding.Mode); ok {
+			// Keep the step from the other block's header to its bindings.
+			step := strings.TrimPrefix(BindingIndent(lines[binding.Line-1]), BindingIndent(lines[otherStart]))
+			indent = BindingIndent(lines[start]) + step
+			break
+		}
+	}
+	return index, indent, nil
+}
+
+// SetBindingAction returns lines with the action of binding replaced,
+// keeping the key and any inline comment verbatim, including the spacing
+// before the #. A binding continued over several lines is collapsed onto its
+// first line.
+func SetBindingAction(lines []string, binding Binding, action string) []string {
+	index := binding.Line - 1
+	prefixRegex := regexp.MustCompile(`^\s*(?:bindsym|bindcode|bindgesture)(?:\s+--[^\s]+)*\s+[^\s]+\s+`)
+	prefix := prefixRegex.FindString(lines[index])
+	if prefix == "" {
+		return lines
+	}
+
+	// An inline comment can only follow the last continuation line.
+	last := lines[binding.EndLine-1]
+	rest := last
+	if binding.EndLine == binding.Line {
+		rest = last[len(prefix):]
+	}
+	newLine := prefix + action + inlineCommentOf(rest)
+	return append(lines[:index], append([]string{newLine}, lines[binding.EndLine:]...)...)
+}
+
+// inlineCommentOf returns the inline comment ending text together with the
+// spacing before its #, or "" if there is none.
+func inlineCommentOf(text string) string {
+	index := CommentIndex(text)
+	if index <= 0 {
+		return ""
+	}
+	code := strings.TrimRight(text[:index], " \t")
+	return text[len(code):]
+}
+
+// ClearBindingComment returns lines with the comment of binding removed:
+// an inline comment is cut from the binding's last line, while a detached
+// comment line above the binding is deleted.
+func ClearBindingComment(lines []string, binding Binding) []string {
+	if binding.InlineComment {
+		last := binding.EndLine - 1
+		lines[last] = strings.TrimSuffix(lines[last], inlineCommentOf(lines[last]))
+		return lines
+	}
+	if start := BindingStart(binding); start != binding.Line-1 {
+		return append(lines[:start], lines[start+1:]...)
+	}
+	return lines
+}
+
+// SetBindingComment returns lines with the comment of binding set. An
+// existing inline comment is replaced in place; otherwise the detached
+// comment line above the binding is updated or inserted, leaving section
+// headers ending in ':' alone.
+func SetBindingComment(lines []string, binding Binding, comment string) []string {
+	i := binding.Line - 1
+
+	if binding.InlineComment {
+		last := binding.EndLine - 1
+		if inline := inlineCommentOf(lines[last]); inline != "" {
+			spacing := inline[:strings.Index(inline, "#")]
+			if spacing == "" {
+				spacing = " "
+			}
+			lines[last] = strings.TrimSuffix(lines[last], inline) + spacing + "# " + comment
+			return lines
+		}
+	}
+
+	commentLine := BindingIndent(lines[i]) + "# " + comment
+	if i > 0 {
+		prevLine := strings.TrimSpace(lines[i-1])
+		if strings.HasPrefix(prevLine, "#") && !disabledRegex.MatchString(prevLine) {
+			trimmed := strings.TrimSpace(strings.TrimPrefix(prevLine, "#"))
+			if strings.HasSuffix(trimmed, ":") {
+				lines = InsertLine(lines, i, commentLine)
+			} else {
+				lines[i-1] = commentLine
+			}
+		} else {
+			lines = InsertLine(lines, i, commentLine)
+		}
+	} else {
+		lines = InsertLine(lines, i, commentLine)
+	}
+	return lines
+}
+
+// SetBindingDisabled returns lines with binding commented out with a
+// "# [disabled]" marker, or with the marker removed again when disabled is
+// false. Parsers other than ParseLinesWithDisabled skip disabled bindings.
+func SetBindingDisabled(lines []string, binding Binding, disabled bool) []string {
+	for index := binding.Line - 1; index < binding.EndLine; index++ {
+		if !disabled {
+			if matches := disabledRegex.FindStringSubmatch(lines[index]); matches != nil {
+				lines[index] = matches[1] + matches[2]
+			}
+		} else {
+			trimmed := strings.TrimLeft(lines[index], " \t")
+			lines[index] = BindingIndent(lines[index]) + "# [disabled] " + trimmed
+		}
+	}
+	return lines
+}
+
+// RemoveBinding returns lines without binding and, unless keepComment is
+// set, its detached comment line.
+func RemoveBinding(lines []string, binding Binding, keepComment bool) []string {
+	start := binding.Line - 1
+	if !keepComment {
+		start = BindingStart(binding)
+	}
+	return append(lines[:start:start], lines[binding.EndLine:]...)
+}
@@ -0,0 +1,197 @@
+package i3config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetBindingAction(t *testing.T) {
+	tests := []struct {
+		name string
+		lines []string
+		action string
+		want []string
+	}{
+		{
+			name: "inline comment survives",
+			lines: []string{"bindsym $mod+q kill # close window"},
+			action: "exec xkill",
+			want: []string{"bindsym $mod+q exec xkill # close window"},
+		},
+		{
+			name: "spacing before comment kept",
+			lines: []string{"bindsym $mod+q kill    #close"},
+			action: "exec xkill",
+			want: []string{"bindsym $mod+q exec xkill    #close"},
+		},
+		{
+			name: "quoted # is not a comment",
+			lines: []string{"bindsym $mod+p exec \"notify-send '#1 done'\" # notify"},
+			action: "exec \"notify-send '#2 done'\"",
+			want: []string{"bindsym $mod+p exec \"notify-send '#2 done'\" # notify"},
+		},
+		{
+			name: "flags and indent kept",
+			lines: []string{"mode \"resize\" {", "\tbindsym --release h resize shrink width 10 px", "}"},
+			action: "resize shrink width 20 px",
+			want: []string{"mode \"resize\" {", "\tbindsym --release h resize shrink width 20 px", "}"},
+		},
+		{
+			name: "continuation collapsed",
+			lines: []string{"bindsym $mod+e exec \\", "    i3-nagbar # ask", "bindsym $mod+q kill"},
+			action: "exec i3-msg exit",
+			want: []string{"bindsym $mod+e exec i3-msg exit # ask", "bindsym $mod+q kill"},
+		},
+	}
+	for _, test := range tests {
+		binding := ParseLines(test.lines)[0]
+		if got := SetBindingAction(test.lines, binding, test.action); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSetBindingComment(t *testing.T) {
+	tests := []struct {
+		name string
+		lines []string
+		want []string
+	}{
+		{"inline replaced", []string{"bindsym $mod+q kill  # old"}, []string{"bindsym $mod+q kill  # new"}},
+		{"above replaced", []string{"# old", "bindsym $mod+q kill"}, []string{"# new", "bindsym $mod+q kill"}},
+		{"inserted", []string{"set $mod Mod4", "bindsym $mod+q kill"}, []string{"set $mod Mod4", "# new", "bindsym $mod+q kill"}},
+		{"header kept", []string{"# Windows:", "\tbindsym $mod+q kill"}, []string{"# Windows:", "\t# new", "\tbindsym $mod+q kill"}},
+		{"continued inline replaced", []string{"bindsym $mod+d exec \\", "    dmenu_run # launcher"}, []string{"bindsym $mod+d exec \\", "    dmenu_run # new"}},
+	}
+	for _, test := range tests {
+		binding := ParseLines(test.lines)[0]
+		if got := SetBindingComment(test.lines, binding, "new"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestClearBindingComment(t *testing.T) {
+	tests := []struct {
+		name string
+		lines []string
+		want []string
+	}{
+		{"inline", []string{"bindsym $mod+q kill   # close window"}, []string{"bindsym $mod+q kill"}},
+		{"inline with quoted #", []string{`bindsym $mod+p exec "notify-send '#1'" # notify`}, []string{`bindsym $mod+p exec "notify-send '#1'"`}},
+		{"detached", []string{"set $mod Mod4", "\t# terminal", "\tbindsym $mod+Return exec alacritty"}, []string{"set $mod Mod4", "\tbindsym $mod+Return exec alacritty"}},
+		{"continued", []string{"bindsym $mod+e exec \\", "    i3-nagbar # ask"}, []string{"bindsym $mod+e exec \\", "    i3-nagbar"}},
+		{"section header kept", []string{"# Apps:", "bindsym $mod+b exec firefox"}, []string{"# Apps:", "bindsym $mod+b exec firefox"}},
+	}
+	for _, test := range tests {
+		binding := ParseLines(test.lines)[0]
+		got := ClearBindingComment(append([]string(nil), test.lines...), binding)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+		if comment := ParseLines(got)[0].Comment; comment != "" {
+			t.Errorf("%s: comment %q left", test.name, comment)
+		}
+	}
+}
+
+func TestInsertBinding(t *testing.T) {
+	lines := []string{
+		"bindsym $mod+q kill",
+		"mode \"resize\" {",
+		"\tbindsym h resize shrink width 10 px",
+		"}",
+		"mode \"empty\" {",
+		"}",
+	}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"", []string{"bindsym $mod+q kill", "bindsym x nop", "mode \"resize\" {", "\tbindsym h resize shrink width 10 px", "}", "mode \"empty\" {", "}"}},
+		{"resize", []string{"bindsym $mod+q kill", "mode \"resize\" {", "\tbindsym h resize shrink width 10 px", "\tbindsym x nop", "}", "mode \"empty\" {", "}"}},
+		{"empty", []string{"bindsym $mod+q kill", "mode \"resize\" {", "\tbindsym h resize shrink width 10 px", "}", "mode \"empty\" {", "\tbindsym x nop", "}"}},
+	}
+	for _, test := range tests {
+		got, err := InsertBinding(append([]string(nil), lines...), "bindsym x nop", test.mode)
+		if err != nil {
+			t.Fatalf("mode %q: %v", test.mode, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("mode %q: got %q, want %q", test.mode, got, test.want)
+		}
+	}
+	if _, err := InsertBinding(lines, "bindsym x nop", "missing"); err == nil {
+		t.Error("InsertBinding into a missing mode succeeded")
+	}
+}
+
+func TestInsertBindingIndent(t *testing.T) {
+	tests := []struct {
+		name string
+		lines []string
+		mode string
+		want []string
+	}{
+		{
+			name: "four spaces in mode",
+			lines: []string{"mode \"resize\" {", "    bindsym h resize shrink", "    bindsym l resize grow", "}"},
+			mode: "resize",
+			want: []string{"mode \"resize\" {", "    bindsym h resize shrink", "    bindsym l resize grow", "    bindsym x nop", "}"},
+		},
+		{
+			name: "empty mode follows other modes",
+			lines: []string{"mode \"resize\" {", "    bindsym h resize shrink", "}", "mode \"empty\" {", "}"},
+			mode: "empty",
+			want: []string{"mode \"resize\" {", "    bindsym h resize shrink", "}", "mode \"empty\" {", "    bindsym x nop", "}"},
+		},
+		{
+			name: "empty mode without others",
+			lines: []string{"bindsym $mod+q kill", "mode \"empty\" {", "}"},
+			mode: "empty",
+			want: []string{"bindsym $mod+q kill", "mode \"empty\" {", "        bindsym x nop", "}"},
+		},
+		{
+			name: "indented globals",
+			lines: []string{"  bindsym $mod+q kill"},
+			want: []string{"  bindsym $mod+q kill", "  bindsym x nop"},
+		},
+	}
+	for _, test := range tests {
+		got, err := InsertBinding(test.lines, "bindsym x nop", test.mode)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSetBindingDisabled(t *testing.T) {
+	lines := []string{"\tbindsym $mod+e exec \\", "\t\ti3-nagbar"}
+	binding := ParseLines(lines)[0]
+	disabled := SetBindingDisabled(append([]string(nil), lines...), binding, true)
+	want := []string{"\t# [disabled] bindsym $mod+e exec \\", "\t\t# [disabled] i3-nagbar"}
+	if !reflect.DeepEqual(disabled, want) {
+		t.Fatalf("disabled: got %q, want %q", disabled, want)
+	}
+	if len(ParseLines(disabled)) != 0 {
+		t.Error("disabled binding is still parsed")
+	}
+	binding = ParseLinesWithDisabled(disabled, true)[0]
+	if got := SetBindingDisabled(disabled, binding, false); !reflect.DeepEqual(got, lines) {
+		t.Errorf("enabled: got %q, want %q", got, lines)
+	}
+}
+
+func TestRemoveBinding(t *testing.T) {
+	lines := []string{"# close", "bindsym $mod+q kill", "bindsym $mod+f fullscreen toggle"}
+	binding := ParseLines(lines)[0]
+	if got, want := RemoveBinding(append([]string(nil), lines...), binding, false), lines[2:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := RemoveBinding(append([]string(nil), lines...), binding, true), []string{lines[0], lines[2]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keepComment: got %q, want %q", got, want)
+	}
+}
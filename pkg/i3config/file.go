@@ -0,0 +1,99 @@
+package i3config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of a UTF-8
+// file.
+const utf8BOM = "\xef\xbb\xbf"
+
+// Format records the line ending, trailing newline and byte order mark of a
+// config file, so it can be written back the way it was read.
+type Format struct {
+	LineEnding string
+	TrailingNewline bool
+	// BOM is set when the file starts with a UTF-8 byte order mark, which
+	// is stripped on read and written back on write.
+	BOM bool
+}
+
+// DefaultFormat is the format of new files: LF line endings and a final
+// newline.
+var DefaultFormat = Format{LineEnding: "\n", TrailingNewline: true}
+
+// SplitLines splits a config's content into lines without their line
+// endings, returning the format to write them back with.
+func SplitLines(data []byte) ([]string, Format) {
+	text := string(data)
+	format := Format{LineEnding: "\n"}
+	if strings.HasPrefix(text, utf8BOM) {
+		format.BOM = true
+		text = strings.TrimPrefix(text, utf8BOM)
+	}
+	if strings.Contains(text, "\r\n") {
+		format.LineEnding = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	format.TrailingNewline = strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n"), format
+}
+
+// JoinLines is the inverse of SplitLines.
+func JoinLines(lines []string, format Format) []byte {
+	content := strings.Join(lines, "\n")
+	if format.TrailingNewline {
+		content += "\n"
+	}
+	if format.LineEnding != "" && format.LineEnding != "\n" {
+		content = strings.ReplaceAll(content, "\n", format.LineEnding)
+	}
+	if format.BOM {
+		content = utf8BOM + content
+	}
+	return []byte(content)
+}
+
+// WriteFile replaces the file at path with content atomically, by writing
+// to a temp file in the same directory and renaming it into place, and gives
+// it the permissions perm. A symlink is left in place with the file it
+// points to replaced, so configs linked in from a dotfiles repo stay linked.
+func WriteFile(path string, content []byte, perm os.FileMode) error {
+	targetPath := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		targetPath = resolved
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	if err := tmpFile.Chmod(perm); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to set config file mode: %v", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, targetPath); err != nil {
+		return fmt.Errorf("failed to replace config file: %v", err)
+	}
+	return nil
+}
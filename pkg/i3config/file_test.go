@@ -0,0 +1,61 @@
+package i3config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitJoinRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		content string
+		lines []string
+	}{
+		{"lf", "a\nb\n", []string{"a", "b"}},
+		{"no final newline", "a\nb", []string{"a", "b"}},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}},
+		{"bom", utf8BOM + "a\nb\n", []string{"a", "b"}},
+		{"blank lines", "a\n\n\nb\n\n", []string{"a", "", "", "b", ""}},
+	}
+	for _, test := range tests {
+		lines, format := SplitLines([]byte(test.content))
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("%s: SplitLines = %q, want %q", test.name, lines, test.lines)
+		}
+		if got := string(JoinLines(lines, format)); got != test.content {
+			t.Errorf("%s: JoinLines = %q, want %q", test.name, got, test.content)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "config")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced: %v", err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil || string(content) != "new\n" {
+		t.Errorf("target = %q, %v", content, err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("target mode = %v, %v", info.Mode().Perm(), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
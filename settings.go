@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Settings read from i3-bind's own config file that aren't flag defaults.
+var (
+	settingsCommentStyle string
+	settingsTemplates = make(map[string]string)
+)
+
+// settingsPath returns the path of i3-bind's own config file.
+func settingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(xdgConfigHome(home), "i3-bind", "config.toml"), nil
+}
+
+// loadSettings applies ~/.config/i3-bind/config.toml. Its top-level keys
+// are flag names, such as no-color = true or picker = "rofi", and set the
+// default of that flag for every command that has it; flags given on the
+// command line still win. comment-style sets the default comment
+// placement, and a [templates] table adds add --template templates.
+func loadSettings(cmd *cobra.Command) error {
+	path, err := settingsPath()
+	if err != nil {
+		return nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	sections, err := parseTOML(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for key, value := range sections[""] {
+		name := strings.ReplaceAll(key, "_", "-")
+		text := fmt.Sprint(value)
+
+		if name == "comment-style" {
+			settingsCommentStyle = text
+			continue
+		}
+
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			if !isFlagOfAnyCommand(cmd.Root(), name) {
+				warningColor.Fprintf(os.Stderr, "Warning: %s: unknown setting %s\n", path, key)
+			}
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(text); err != nil {
+			return fmt.Errorf("%s: invalid %s %q: %v", path, key, text, err)
+		}
+	}
+
+	for name, value := range sections["templates"] {
+		action, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: template %s must be a string", path, name)
+		}
+		settingsTemplates[name] = action
+	}
+	return nil
+}
+
+// isFlagOfAnyCommand reports whether name is a flag of cmd or any command
+// below it, so settings meant for other commands aren't reported.
+func isFlagOfAnyCommand(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if isFlagOfAnyCommand(sub, name) {
+			return true
+		}
+	}
+	return false
+}
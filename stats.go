@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// bindingStats is the report of stats. Its maps are always allocated, so
+// empty categories encode as {} rather than null.
+type bindingStats struct {
+	Total int `json:"total"`
+	ByModifier map[string]int `json:"byModifier"`
+	ExecCount int `json:"execCount"`
+	BuiltinCount int `json:"builtinCount"`
+	CommentedCount int `json:"commentedCount"`
+	InModes int `json:"inModes"`
+	Modes map[string]int `json:"modes"`
+	Programs map[string]int `json:"programs"`
+}
+
+// modifierName returns the display name of a modifier token, folding case
+// variants such as shift and SHIFT together.
+func modifierName(token string) string {
+	lower := strings.ToLower(token)
+	switch {
+	case token == "" || strings.HasPrefix(token, "$"):
+		return token
+	case lower == "ctrl" || lower == "control":
+		return "Control"
+	case strings.HasPrefix(lower, "mod"):
+		return "Mod" + lower[3:]
+	}
+	first, size := utf8.DecodeRuneInString(lower)
+	return string(unicode.ToUpper(first)) + lower[size:]
+}
+
+func collectStats(bindings []Binding) bindingStats {
+	stats := bindingStats{
+		Total: len(bindings),
+		ByModifier: make(map[string]int),
+		Modes: make(map[string]int),
+		Programs: make(map[string]int),
+	}
+
+	for _, binding := range bindings {
+		tokens := strings.Split(binding.Key, "+")
+		seen := make(map[string]bool)
+		for _, token := range tokens[:len(tokens)-1] {
+			if token == "" {
+				continue
+			}
+			name := modifierName(token)
+			if !seen[name] {
+				seen[name] = true
+				stats.ByModifier[name]++
+			}
+		}
+
+		if execs := actionExecs(binding.Action); len(execs) > 0 {
+			stats.ExecCount++
+			for _, exec := range execs {
+				stats.Programs[exec.Program]++
+			}
+		} else {
+			stats.BuiltinCount++
+		}
+		if binding.Comment != "" {
+			stats.CommentedCount++
+		}
+		if binding.Mode != "" {
+			stats.InModes++
+		}
+		stats.Modes[modeLabel(binding.Mode)]++
+	}
+	return stats
+}
+
+// sortedCounts returns the keys of counts ordered by descending count and
+// then by name.
+func sortedCounts(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func showStats(cmd *cobra.Command, args []string) {
+	_, bindings, err := loadBindings()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	stats := collectStats(bindings)
+	if jsonOutput {
+		printResult("ok", "", stats)
+		return
+	}
+	switch statsFormat {
+	case "json":
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fatalf(exitError, "failed to encode JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	case "text":
+	default:
+		fatalf(exitError, "Unknown format %s (expected text or json)", statsFormat)
+	}
+
+	fmt.Printf("Keybinding statistics for %s:\n\n", configPath)
+	fmt.Printf("  %-12s %s\n", "Total:", successColor.Sprint(stats.Total))
+	fmt.Printf("  %-12s %s\n", "exec:", actionColor.Sprint(stats.ExecCount))
+	fmt.Printf("  %-12s %s\n", "Built-in:", actionColor.Sprint(stats.BuiltinCount))
+	fmt.Printf("  %-12s %s\n", "Commented:", commentColor.Sprint(stats.CommentedCount))
+	fmt.Printf("  %-12s %s\n", "In modes:", modeColor.Sprint(stats.InModes))
+
+	if len(stats.ByModifier) > 0 {
+		fmt.Println("\nModifiers:")
+		for _, name := range sortedCounts(stats.ByModifier) {
+			fmt.Printf("  %s %s\n", keyColor.Sprintf("%-12s", name), actionColor.Sprint(stats.ByModifier[name]))
+		}
+	}
+
+	if len(stats.Programs) > 0 {
+		fmt.Println("\nPrograms:")
+		for _, name := range sortedCounts(stats.Programs) {
+			fmt.Printf("  %s %s\n", actionColor.Sprintf("%-12s", name), actionColor.Sprint(stats.Programs[name]))
+		}
+	}
+
+	if len(stats.Modes) > 0 {
+		fmt.Println("\nModes:")
+		for _, name := range sortedCounts(stats.Modes) {
+			fmt.Printf("  %s %s\n", modeColor.Sprintf("%-12s", name), actionColor.Sprint(stats.Modes[name]))
+		}
+	}
+}
+
+func countBindings(cmd *cobra.Command, args []string) {
+	lines, bindings, err := loadBindings()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+
+	if expandVars {
+		bindings = expandBindings(bindings, parseVariables(lines))
+	}
+	count := len(countFilter.apply(bindings))
+	if jsonOutput {
+		printResult("ok", "", count)
+		return
+	}
+	fmt.Println(count)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"i3-bind/pkg/i3config"
+)
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// closestKeys returns up to three bound keys within a few typos of key,
+// closest first. Keys are compared normalized, so modifier order and case
+// don't count as typos.
+func closestKeys(bindings []Binding, key string) []string {
+	target := i3config.NormalizeKey(key)
+	// Allow one typo per four characters, so a short key such as h isn't
+	// "close" to every other letter.
+	limit := min(3, len([]rune(target))/4)
+	if limit == 0 {
+		return nil
+	}
+
+	distances := make(map[string]int)
+	var keys []string
+	for _, binding := range bindings {
+		if _, seen := distances[binding.Key]; seen {
+			continue
+		}
+		if distance := levenshtein(target, i3config.NormalizeKey(binding.Key)); distance <= limit {
+			distances[binding.Key] = distance
+			keys = append(keys, binding.Key)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return distances[keys[i]] < distances[keys[j]]
+	})
+	if len(keys) > 3 {
+		keys = keys[:3]
+	}
+	return keys
+}
+
+// commonAction is a capability most i3 setups bind, with the key and action
+// i3's default config uses for it.
+type commonAction struct {
+	Name string `json:"name"`
+	Key string `json:"key"`
+	Action string `json:"action"`
+	pattern *regexp.Regexp
+}
+
+// commonActions are matched against each command of every bound action.
+var commonActions = []commonAction{
+	{"Launch a terminal", "$mod+Return", "exec i3-sensible-terminal",
+		regexp.MustCompile(`^exec\b.*\b(i3-sensible-terminal|x-terminal-emulator|terminal|alacritty|kitty|foot|wezterm|urxvt|xterm|st|konsole|gnome-terminal|xfce4-terminal|terminator)\b`)},
+	{"Open an application launcher", "$mod+d", "exec --no-startup-id dmenu_run",
+		regexp.MustCompile(`^exec\b.*\b(dmenu_run|dmenu|rofi|i3-dmenu-desktop|wofi|fuzzel|bemenu-run|j4-dmenu-desktop)\b`)},
+	{"Close the focused window", "$mod+Shift+q", "kill",
+		regexp.MustCompile(`^kill\b`)},
+	{"Toggle fullscreen", "$mod+f", "fullscreen toggle",
+		regexp.MustCompile(`^fullscreen\b`)},
+	{"Toggle floating", "$mod+Shift+space", "floating toggle",
+		regexp.MustCompile(`^floating\s+(toggle|enable)\b`)},
+	{"Switch focus between tiling and floating", "$mod+space", "focus mode_toggle",
+		regexp.MustCompile(`^focus\s+mode_toggle\b`)},
+	{"Move focus between windows", "$mod+Left", "focus left",
+		regexp.MustCompile(`^focus\s+(left|right|up|down)\b`)},
+	{"Move windows", "$mod+Shift+Left", "move left",
+		regexp.MustCompile(`^move\s+(left|right|up|down)\b`)},
+	{"Switch workspaces", "$mod+1", "workspace number 1",
+		regexp.MustCompile(`^workspace\s+`)},
+	{"Move windows to another workspace", "$mod+Shift+1", "move container to workspace number 1",
+		regexp.MustCompile(`^move\s+(container\s+|window\s+)?to\s+workspace\b`)},
+	{"Split horizontally", "$mod+h", "split h",
+		regexp.MustCompile(`^split\s+(h|horizontal|toggle)\b`)},
+	{"Split vertically", "$mod+v", "split v",
+		regexp.MustCompile(`^split\s+(v|vertical|toggle)\b`)},
+	{"Change the container layout", "$mod+e", "layout toggle split",
+		regexp.MustCompile(`^layout\b`)},
+	{"Resize windows", "$mod+r", `mode "resize"`,
+		regexp.MustCompile(`^(mode\s+"?resize"?|resize\s)`)},
+	{"Use the scratchpad", "$mod+minus", "scratchpad show",
+		regexp.MustCompile(`^scratchpad\s+show\b`)},
+	{"Reload the config", "$mod+Shift+c", "reload",
+		regexp.MustCompile(`^reload\b`)},
+	{"Restart i3 in place", "$mod+Shift+r", "restart",
+		regexp.MustCompile(`^restart\b`)},
+	{"Exit i3", "$mod+Shift+e", "exit",
+		regexp.MustCompile(`^(exit\b|exec\b.*\bi3-nagbar\b)`)},
+}
+
+// unboundActions returns the common actions that no binding performs.
+// Variables are expanded first, so exec $term counts as a terminal.
+func unboundActions(bindings []Binding, vars map[string]string) []commonAction {
+	var unbound []commonAction
+	for _, common := range commonActions {
+		bound := false
+		for _, binding := range bindings {
+			for _, command := range i3config.SplitCommands(expandVariables(binding.Action, vars)) {
+				if common.pattern.MatchString(strings.TrimSpace(command)) {
+					bound = true
+					break
+				}
+			}
+			if bound {
+				break
+			}
+		}
+		if !bound {
+			unbound = append(unbound, common)
+		}
+	}
+	return unbound
+}
+
+// shellQuote quotes s in single quotes for a POSIX shell, so a printed
+// command can be pasted as is.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// printUnbound lists the common actions without a binding, each with the
+// add command that binds it the way i3's default config does.
+func printUnbound(bindings []Binding, vars map[string]string) {
+	unbound := unboundActions(bindings, vars)
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Found %d common action(s) without a keybinding", len(unbound)), append([]commonAction{}, unbound...))
+		return
+	}
+
+	if len(unbound) == 0 {
+		printSuccess("✓ All %d common actions have a keybinding\n", len(commonActions))
+		return
+	}
+
+	fmt.Printf("%d common action(s) have no keybinding:\n\n", len(unbound))
+	for _, common := range unbound {
+		fmt.Printf("  %s\n", common.Name)
+		fmt.Printf("    i3-bind add %s %s\n", keyColor.Sprint(shellQuote(common.Key)), actionColor.Sprint(shellQuote(common.Action)))
+	}
+}
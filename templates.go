@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultTemplates are available to add --template without a templates
+// file. Entries in the file override them.
+var defaultTemplates = map[string]string{
+	"term": "exec --no-startup-id i3-sensible-terminal",
+	"browser": "exec --no-startup-id firefox",
+	"dmenu": "exec --no-startup-id dmenu_run",
+	"rofi": "exec --no-startup-id rofi -show drun",
+	"lock": "exec --no-startup-id i3lock",
+}
+
+// templatesPath returns where add --template looks for templates.
+func templatesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(xdgConfigHome(home), "i3-bind", "templates.toml"), nil
+}
+
+// loadTemplates returns the default templates merged with the [templates]
+// table of i3-bind's config file and then the name = "action" pairs of the
+// templates file, if there is one.
+func loadTemplates() (map[string]string, error) {
+	templates := make(map[string]string)
+	for name, action := range defaultTemplates {
+		templates[name] = action
+	}
+	for name, action := range settingsTemplates {
+		templates[name] = action
+	}
+
+	path, err := templatesPath()
+	if err != nil {
+		return templates, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	sections, err := parseTOML(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for name, value := range sections[""] {
+		action, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: template %s must be a string", path, name)
+		}
+		templates[name] = action
+	}
+	return templates, nil
+}
+
+// expandTemplate returns the action for a named template with any extra
+// arguments appended, so "-t term -- -e htop" adds to the command.
+func expandTemplate(name string, extra []string) (string, error) {
+	templates, err := loadTemplates()
+	if err != nil {
+		return "", err
+	}
+	action, ok := templates[name]
+	if !ok {
+		names := make([]string, 0, len(templates))
+		for known := range templates {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown template %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	return strings.TrimSpace(action + " " + strings.Join(extra, " ")), nil
+}
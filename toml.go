@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// tomlSections holds the keys of a TOML file by table; keys before the
+// first [table] header are in the "" table.
+type tomlSections map[string]map[string]interface{}
+
+// parseTOML decodes one of i3-bind's own config files, splitting its
+// top-level [table]s from the keys outside any table.
+func parseTOML(content string) (tomlSections, error) {
+	var decoded map[string]interface{}
+	if _, err := toml.Decode(content, &decoded); err != nil {
+		return nil, err
+	}
+
+	sections := tomlSections{"": {}}
+	for key, value := range decoded {
+		if table, ok := value.(map[string]interface{}); ok {
+			sections[key] = table
+			continue
+		}
+		sections[""][key] = value
+	}
+	return sections, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	setRegex = regexp.MustCompile(`^\s*set\s+(\$[^\s]+)\s+(.*?)\s*$`)
+	setFromResourceRegex = regexp.MustCompile(`^\s*set_from_resource\s+(\$[^\s]+)\s+[^\s]+(?:\s+(.*?))?\s*$`)
+	variableRefRegex = regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_-]*`)
+)
+
+// parseVariables collects the variables defined with `set $name value` and
+// `set_from_resource $name resource fallback`, resolving references to other
+// variables. X resources aren't queried, so set_from_resource variables take
+// their fallback value. Later definitions win, as in i3.
+func parseVariables(lines []string) map[string]string {
+	raw := make(map[string]string)
+	for _, line := range lines {
+		if matches := setRegex.FindStringSubmatch(line); matches != nil {
+			raw[matches[1]] = matches[2]
+		} else if matches := setFromResourceRegex.FindStringSubmatch(line); matches != nil {
+			raw[matches[1]] = matches[2]
+		}
+	}
+	return resolveVariables(raw)
+}
+
+// unresolvedVariables returns the $variables left in text after expansion,
+// each once.
+func unresolvedVariables(text string, vars map[string]string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range variableRefRegex.FindAllString(expandVariables(text, vars), -1) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolveVariables expands variables that reference other variables. A
+// variable that is part of a cycle keeps its references unexpanded.
+func resolveVariables(raw map[string]string) map[string]string {
+	resolved := make(map[string]string)
+	visiting := make(map[string]bool)
+
+	var resolve func(name string) string
+	resolve = func(name string) string {
+		if value, ok := resolved[name]; ok {
+			return value
+		}
+		if visiting[name] {
+			return name
+		}
+		visiting[name] = true
+
+		value := raw[name]
+		for _, ref := range variableNames(raw) {
+			if ref != name && strings.Contains(value, ref) {
+				value = strings.ReplaceAll(value, ref, resolve(ref))
+			}
+		}
+
+		visiting[name] = false
+		resolved[name] = value
+		return value
+	}
+
+	for name := range raw {
+		resolve(name)
+	}
+	return resolved
+}
+
+// variableNames returns the variable names longest first, so that $mod_alt
+// is substituted before $mod.
+func variableNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// expandVariables substitutes every known variable in text.
+func expandVariables(text string, vars map[string]string) string {
+	if !strings.Contains(text, "$") {
+		return text
+	}
+	for _, name := range variableNames(vars) {
+		text = strings.ReplaceAll(text, name, vars[name])
+	}
+	return text
+}
+
+// expandBindings returns copies of bindings with variables substituted in
+// their keys, actions and mode switch targets.
+func expandBindings(bindings []Binding, vars map[string]string) []Binding {
+	expanded := make([]Binding, len(bindings))
+	for i, binding := range bindings {
+		binding.Key = expandVariables(binding.Key, vars)
+		binding.Action = expandVariables(binding.Action, vars)
+		binding.ModeSwitch = strings.Trim(expandVariables(binding.ModeSwitch, vars), `"`)
+		expanded[i] = binding
+	}
+	return expanded
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// watchSettle is how long watch waits after the last event before checking,
+// so a save that arrives as several events is checked once.
+const watchSettle = 100 * time.Millisecond
+
+// watchTargets returns the absolute paths of the config and every file it
+// includes, and the directories they are in. A symlinked file, such as a
+// config kept in a dotfiles repo, is also watched under the path it
+// resolves to, since saving it changes the target's directory.
+func watchTargets() (map[string]bool, []string, error) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make(map[string]bool)
+	var dirs []string
+	seenDirs := make(map[string]bool)
+	add := func(path string) {
+		paths[path] = true
+		if dir := filepath.Dir(path); !seenDirs[dir] {
+			seenDirs[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, file := range files {
+		path, err := filepath.Abs(file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		add(path)
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path {
+			add(resolved)
+		}
+	}
+	return paths, dirs, nil
+}
+
+// watchConfig checks the config with i3 -C whenever it or one of its
+// included files changes, and reloads the window manager when it passes.
+// The directories of the files are watched rather than the files, so
+// editors that save by renaming a new file into place are seen too.
+func watchConfig(cmd *cobra.Command, args []string) {
+	if configPath == "-" {
+		fatalf(exitError, "cannot watch a config read from stdin")
+	}
+	if jsonOutput {
+		fatalf(exitError, "watch doesn't support --json")
+	}
+
+	wm := "i3"
+	if isSwayConfig() {
+		wm = "sway"
+	}
+	if _, err := exec.LookPath(wm); err != nil {
+		fatalf(exitError, "%s not found in PATH, cannot check the config", wm)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fatalf(exitError, "failed to watch the config: %v", err)
+	}
+	defer watcher.Close()
+
+	paths, dirs, err := watchTargets()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			fatalf(exitError, "failed to watch %s: %v", dir, err)
+		}
+	}
+	printInfo("Watching %s for changes, press Ctrl-C to stop\n", configPath)
+
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if paths[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+				settle.Reset(watchSettle)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			warningColor.Fprintf(os.Stderr, "Warning: %v\n", err)
+		case <-settle.C:
+			printInfo("\n%s changed at %s\n", configPath, time.Now().Format("15:04:05"))
+			output, runErr := exec.Command(wm, "-C", "-c", configPath).CombinedOutput()
+			if errorCount := printCheckOutput(string(output)); errorCount > 0 || runErr != nil {
+				if errorCount > 0 {
+					errorColor.Printf("%s found %d error(s) in %s, not reloading\n", wm, errorCount, configPath)
+				} else {
+					errorColor.Printf("%s -C failed for %s: %v, not reloading\n", wm, configPath, runErr)
+				}
+			} else {
+				reloadWindowManager()
+			}
+
+			// Includes may have been added or removed by the save.
+			if newPaths, newDirs, err := watchTargets(); err == nil {
+				paths = newPaths
+				for _, dir := range newDirs {
+					if err := watcher.Add(dir); err != nil {
+						warningColor.Fprintf(os.Stderr, "Warning: failed to watch %s: %v\n", dir, err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"i3-bind/pkg/i3config"
+)
+
+// bindingLocation is where a binding is defined, for which.
+type bindingLocation struct {
+	File string `json:"file"`
+	Line int `json:"line"`
+	Binding Binding `json:"binding"`
+}
+
+// whichBinding prints the absolute file path and line of every definition of
+// a key, in every mode and including disabled ones. Keys also match when they
+// only agree once $variables are expanded, so Mod4+Return finds $mod+Return.
+func whichBinding(cmd *cobra.Command, args []string) {
+	files, err := loadConfigFiles()
+	if err != nil {
+		fatalf(exitCode(err), "%v", err)
+	}
+	vars := parseVariables(allLines(files))
+	key := args[0]
+
+	bindings := allBindingsWithDisabled(files, true)
+	locations := []bindingLocation{}
+	for _, binding := range bindings {
+		if !i3config.KeysMatch(binding.Key, key) && !i3config.KeysMatch(expandVariables(binding.Key, vars), expandVariables(key, vars)) {
+			continue
+		}
+		path, err := filepath.Abs(binding.SourceFile)
+		if err != nil {
+			path = binding.SourceFile
+		}
+		locations = append(locations, bindingLocation{File: path, Line: binding.Line, Binding: binding})
+	}
+
+	if len(locations) == 0 {
+		fatalf(exitNotFound, "%v", keyNotFoundError(bindings, key))
+	}
+
+	if jsonOutput {
+		printResult("ok", fmt.Sprintf("Found %d definition(s) of %s", len(locations), key), locations)
+		return
+	}
+
+	for _, location := range locations {
+		binding := location.Binding
+		fmt.Printf("%s:%d: %s -> %s", location.File, location.Line, formatKey(binding), actionColor.Sprint(binding.Action))
+		if binding.Mode != "" {
+			fmt.Printf(" %s", modeColor.Sprintf("[mode: %s]", binding.Mode))
+		}
+		if binding.Disabled {
+			fmt.Printf(" %s", disabledColor.Sprint("[disabled]"))
+		}
+		fmt.Println()
+	}
+}